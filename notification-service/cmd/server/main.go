@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"time"
 
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/codec"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/config"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/database"
 	httpserver "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/observability/metrics"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/observability/tracing"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/rabbitmq"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/schema"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/service"
 )
 
@@ -50,17 +52,29 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
+	schemaRegistry, err := schema.NewRegistry()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	codecs, err := codec.NewRegistry()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
 	rmq, err := rabbitmq.NewRabbitMQ(ctx, &rabbitmq.Opts{
 		Config:                  cfg.AMQP,
 		Logger:                  log,
 		ProcessedMessageService: processedMessageService,
 		DB:                      db,
+		SchemaRegistry:          schemaRegistry,
+		Codecs:                  codecs,
 	})
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
-	metricsService := metrics.NewMetricsService(cfg.Metrics, metrics.ConsumerMetrics{})
+	metricsService := metrics.NewMetricsService(cfg.Metrics, metrics.ConsumerMetrics{}, metrics.RabbitMQMetrics{})
 	healthService := service.NewHealthService(&service.HealthServiceOpts{
 		Checks: map[string]service.DependencyHealthCheck{
 			"rabbitmq": func(ctx context.Context) error {
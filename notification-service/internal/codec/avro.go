@@ -0,0 +1,52 @@
+package codec
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/hamba/avro/v2"
+)
+
+//go:embed avro
+var avroSchemas embed.FS
+
+// AvroCodec decodes Avro binary payloads with the writer schema named by the
+// schema reference, e.g. "order.created/v1" for avro/order.created/v1.avsc.
+type AvroCodec struct {
+	schemas map[string]avro.Schema
+}
+
+func NewAvroCodec() (*AvroCodec, error) {
+	c := &AvroCodec{schemas: map[string]avro.Schema{}}
+
+	files, err := fs.Glob(avroSchemas, "avro/*/*.avsc")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(avroSchemas, file)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := avro.ParseBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("avro schema %s: %w", file, err)
+		}
+
+		ref := strings.TrimSuffix(strings.TrimPrefix(file, "avro/"), ".avsc")
+		c.schemas[ref] = schema
+	}
+
+	return c, nil
+}
+
+func (c *AvroCodec) Decode(data []byte, schemaRef string, v any) error {
+	schema, ok := c.schemas[schemaRef]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownSchema, schemaRef)
+	}
+
+	return avro.Unmarshal(schema, data, v)
+}
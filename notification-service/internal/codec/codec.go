@@ -0,0 +1,87 @@
+// Package codec decodes message payloads into typed events by content type.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+var (
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	ErrUnknownSchema          = errors.New("unknown schema reference")
+)
+
+// Codec decodes data encoded with the schema named by schemaRef into v.
+type Codec interface {
+	Decode(data []byte, schemaRef string, v any) error
+}
+
+type Registry struct {
+	codecs map[string]Codec
+}
+
+// NewRegistry returns a registry with the JSON, Avro and Protobuf codecs.
+func NewRegistry() (*Registry, error) {
+	avroCodec, err := NewAvroCodec()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{codecs: map[string]Codec{}}
+	r.Register(ContentTypeJSON, JSONCodec{})
+	r.Register(ContentTypeAvro, avroCodec)
+	r.Register(ContentTypeProtobuf, ProtobufCodec{})
+
+	return r, nil
+}
+
+// Register sets the codec of a media type, parameters are ignored on lookup.
+func (r *Registry) Register(contentType string, codec Codec) {
+	r.codecs[contentType] = codec
+}
+
+// Decode picks the codec by content type, an empty content type is JSON.
+func (r *Registry) Decode(contentType, schemaRef string, data []byte, v any) error {
+	mediaType := ContentTypeJSON
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+		}
+	}
+
+	codec, ok := r.codecs[mediaType]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnsupportedContentType, mediaType)
+	}
+
+	return codec.Decode(data, schemaRef, v)
+}
+
+// IsJSON reports whether contentType is application/json or a +json type.
+func IsJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Decode(data []byte, schemaRef string, v any) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,25 @@
+package codec
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufCodec decodes into generated message types. The schema reference
+// is the full message name and has to match the target's.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Decode(data []byte, schemaRef string, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T is not a protobuf message", ErrUnsupportedContentType, v)
+	}
+
+	name := string(msg.ProtoReflect().Descriptor().FullName())
+	if schemaRef != "" && schemaRef != name {
+		return fmt.Errorf("%w %q, expected %s", ErrUnknownSchema, schemaRef, name)
+	}
+
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,30 @@
+// Package event holds the typed events this service consumes.
+package event
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownEventKey = errors.New("unknown event key")
+
+// OrderCreated is published by order-service for every new order.
+type OrderCreated struct {
+	ID        int64  `json:"id" avro:"id"`
+	ProductID string `json:"product_id" avro:"product_id"`
+	Quantity  int    `json:"quantity" avro:"quantity"`
+}
+
+var types = map[string]func() any{
+	"order.created": func() any { return &OrderCreated{} },
+}
+
+// New returns a pointer to a zero event of the event key's type.
+func New(eventKey string) (any, error) {
+	newEvent, ok := types[eventKey]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEventKey, eventKey)
+	}
+
+	return newEvent(), nil
+}
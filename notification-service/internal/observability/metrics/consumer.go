@@ -27,6 +27,20 @@ var (
 		Name: "consumer_dlq_publish_failed_total",
 		Help: "Total number of consumer messages that failed to publish to the dead-letter queue.",
 	})
+	ConsumerDecompressedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "consumer_decompressed_total",
+			Help: "Total number of compressed messages decompressed by the consumer.",
+		},
+		[]string{"encoding"}, // gzip | zstd
+	)
+	ConsumerInvalidMessagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "consumer_invalid_messages_total",
+			Help: "Total number of consumer messages rejected for not matching their schema.",
+		},
+		[]string{"event_key"},
+	)
 )
 
 type ConsumerMetrics struct{}
@@ -39,5 +53,7 @@ func (ConsumerMetrics) Register(r *prometheus.Registry) {
 		ConsumerRetryExhaustedTotal,
 		ConsumerDLQPublishedTotal,
 		ConsumerDLQPublishFailedTotal,
+		ConsumerInvalidMessagesTotal,
+		ConsumerDecompressedTotal,
 	)
 }
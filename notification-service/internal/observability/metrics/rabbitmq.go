@@ -0,0 +1,31 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	RabbitMQConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rabbitmq_connected",
+		Help: "Whether the RabbitMQ connection is currently open (1) or not (0).",
+	})
+	RabbitMQReconnectsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rabbitmq_reconnects_total",
+			Help: "Total number of RabbitMQ reconnect attempts after a lost connection.",
+		},
+		[]string{"result"}, // success | error
+	)
+	RabbitMQResubscribesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rabbitmq_consumer_resubscribes_total",
+		Help: "Total number of times the consumer resubscribed after its channel closed.",
+	})
+)
+
+type RabbitMQMetrics struct{}
+
+func (RabbitMQMetrics) Register(r *prometheus.Registry) {
+	r.MustRegister(
+		RabbitMQConnected,
+		RabbitMQReconnectsTotal,
+		RabbitMQResubscribesTotal,
+	)
+}
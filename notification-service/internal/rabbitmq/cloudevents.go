@@ -0,0 +1,165 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/constant"
+)
+
+const (
+	cloudEventsHeaderPrefix     = "cloudEvents:"
+	cloudEventsContentType      = "application/cloudevents+json"
+	cloudEventsSpecVersionMajor = "1."
+)
+
+// Message is a delivery with its CloudEvents attributes resolved, the same
+// for bare payloads and both CloudEvents content modes. Bare payloads take
+// their ID from the message ID, their type from the routing key and their
+// schema reference from the AMQP type property. CloudEvents carry it in the
+// schemaref extension, dataschema is only used by producers without it.
+type Message struct {
+	EventKey    string // routing key the producer published under
+	ID          string
+	Type        string
+	Source      string
+	Subject     string
+	Time        time.Time
+	ContentType string
+	SchemaRef   string
+	Data        []byte
+}
+
+// structuredCloudEvent is the JSON event format.
+type structuredCloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time"`
+	Subject         string          `json:"subject"`
+	DataSchema      string          `json:"dataschema"`
+	SchemaRef       string          `json:"schemaref"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+	DataBase64      []byte          `json:"data_base64"`
+}
+
+func decodeMessage(delivery amqp091.Delivery) (*Message, error) {
+	mediaType, _, _ := mime.ParseMediaType(delivery.ContentType)
+
+	var (
+		msg *Message
+		err error
+	)
+	switch {
+	case mediaType == cloudEventsContentType:
+		msg, err = decodeStructured(delivery.Body)
+	case delivery.Headers[cloudEventsHeaderPrefix+"specversion"] != nil:
+		msg, err = decodeBinary(delivery)
+	default:
+		msg = &Message{
+			ID:          delivery.MessageId,
+			Type:        delivery.RoutingKey,
+			Time:        delivery.Timestamp,
+			ContentType: delivery.ContentType,
+			SchemaRef:   delivery.Type,
+			Data:        delivery.Body,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg.EventKey = delivery.RoutingKey
+	return msg, nil
+}
+
+func decodeBinary(delivery amqp091.Delivery) (*Message, error) {
+	attr := func(name string) string {
+		value, _ := delivery.Headers[cloudEventsHeaderPrefix+name].(string)
+		return value
+	}
+
+	if err := checkSpecVersion(attr("specversion")); err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		ID:          attr("id"),
+		Type:        attr("type"),
+		Source:      attr("source"),
+		Subject:     attr("subject"),
+		ContentType: delivery.ContentType,
+		SchemaRef:   schemaRef(attr("schemaref"), attr("dataschema")),
+		Data:        delivery.Body,
+	}
+	if err := msg.parseTime(attr("time")); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func decodeStructured(body []byte) (*Message, error) {
+	var ce structuredCloudEvent
+	if err := json.Unmarshal(body, &ce); err != nil {
+		return nil, fmt.Errorf("%w: invalid structured cloudevent: %v", constant.ErrPermanent, err)
+	}
+	if err := checkSpecVersion(ce.SpecVersion); err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		ID:          ce.ID,
+		Type:        ce.Type,
+		Source:      ce.Source,
+		Subject:     ce.Subject,
+		ContentType: ce.DataContentType,
+		SchemaRef:   schemaRef(ce.SchemaRef, ce.DataSchema),
+		Data:        ce.Data,
+	}
+	if ce.DataBase64 != nil {
+		msg.Data = ce.DataBase64
+	}
+	if err := msg.parseTime(ce.Time); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// schemaRef prefers the schemaref extension, dataschema may be overridden
+// with a URL the codecs cannot resolve.
+func schemaRef(extension, dataSchema string) string {
+	if extension != "" {
+		return extension
+	}
+	return dataSchema
+}
+
+func (m *Message) parseTime(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return fmt.Errorf("%w: invalid cloudevent time %q", constant.ErrPermanent, value)
+	}
+	m.Time = t
+
+	return nil
+}
+
+func checkSpecVersion(version string) error {
+	if !strings.HasPrefix(version, cloudEventsSpecVersionMajor) {
+		return fmt.Errorf("%w: unsupported cloudevents specversion %q", constant.ErrPermanent, version)
+	}
+
+	return nil
+}
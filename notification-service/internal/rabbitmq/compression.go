@@ -0,0 +1,74 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/constant"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/observability/metrics"
+)
+
+const maxDecompressedSize = 64 << 20
+
+// zstdDecoder is built once and shared, DecodeAll is safe for concurrent use.
+var zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+})
+
+// decompress returns the delivery with its body decoded according to its
+// content encoding. The original delivery is left as is, so retries and the
+// DLQ get the message exactly as it was published.
+func decompress(message amqp091.Delivery) (amqp091.Delivery, error) {
+	var (
+		body []byte
+		err  error
+	)
+
+	switch message.ContentEncoding {
+	case "", "identity":
+		return message, nil
+	case "gzip":
+		body, err = gunzip(message.Body)
+	case "zstd":
+		decoder, decoderErr := zstdDecoder()
+		if decoderErr != nil {
+			// Not the message's fault, so it is retried rather than dead-lettered.
+			return message, fmt.Errorf("failed to create zstd decoder: %w", decoderErr)
+		}
+		body, err = decoder.DecodeAll(message.Body, nil)
+	default:
+		return message, fmt.Errorf("%w: unsupported content encoding %q", constant.ErrPermanent, message.ContentEncoding)
+	}
+	if err != nil {
+		return message, fmt.Errorf("%w: failed to decompress %s body: %v", constant.ErrPermanent, message.ContentEncoding, err)
+	}
+
+	metrics.ConsumerDecompressedTotal.WithLabelValues(message.ContentEncoding).Inc()
+
+	message.Body = body
+	message.ContentEncoding = ""
+	return message, nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	body, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxDecompressedSize {
+		return nil, fmt.Errorf("body exceeds %d bytes", maxDecompressedSize)
+	}
+
+	return body, nil
+}
@@ -2,14 +2,15 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/codec"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/constant"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/database/model"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/event"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/logger"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/observability/metrics"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/observability/tracing"
@@ -18,12 +19,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// Consume subscribes to the queue and keeps the subscription alive: whenever
+// the deliveries channel closes, e.g. after a lost connection, it resubscribes
+// with capped backoff until ctx is done or the client is closed.
 func (r *RabbitMQ) Consume(ctx context.Context) error {
-	ch, err := r.NewChannel()
+	ch, messages, err := r.subscribe()
 	if err != nil {
 		return err
 	}
 
+	go r.consume(ctx, ch, messages)
+
+	return nil
+}
+
+func (r *RabbitMQ) subscribe() (*amqp091.Channel, <-chan amqp091.Delivery, error) {
+	ch, err := r.NewChannel()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	messages, err := ch.Consume(
 		r.Config.Queue,
 		"",
@@ -34,14 +49,54 @@ func (r *RabbitMQ) Consume(ctx context.Context) error {
 		nil,
 	)
 	if err != nil {
-		return err
+		_ = ch.Close()
+		return nil, nil, err
 	}
 
 	r.Log.Info("AMQP listening for messages", logger.Field{Key: "queue", Value: r.Config.Queue})
 
-	go r.processMessages(ctx, ch, messages)
+	return ch, messages, nil
+}
 
-	return nil
+func (r *RabbitMQ) consume(ctx context.Context, ch *amqp091.Channel, messages <-chan amqp091.Delivery) {
+	for {
+		r.processMessages(ctx, ch, messages)
+		_ = ch.Close()
+
+		var ok bool
+		if ch, messages, ok = r.resubscribe(ctx); !ok {
+			return
+		}
+	}
+}
+
+func (r *RabbitMQ) resubscribe(ctx context.Context) (*amqp091.Channel, <-chan amqp091.Delivery, bool) {
+	delay := reconnectMinBackoff
+
+	for {
+		if ctx.Err() != nil || r.isClosing() {
+			return nil, nil, false
+		}
+
+		r.Log.Warn("AMQP consumer stopped, resubscribing",
+			logger.Field{Key: "queue", Value: r.Config.Queue},
+			logger.Field{Key: "backoff_seconds", Value: delay.Seconds()},
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, false
+		case <-time.After(delay):
+		}
+
+		ch, messages, err := r.subscribe()
+		if err == nil {
+			metrics.RabbitMQResubscribesTotal.Inc()
+			return ch, messages, true
+		}
+
+		delay = min(delay*2, reconnectMaxBackoff)
+	}
 }
 
 func (r *RabbitMQ) processMessages(ctx context.Context, ch *amqp091.Channel, messages <-chan amqp091.Delivery) {
@@ -63,7 +118,10 @@ func (r *RabbitMQ) processMessages(ctx context.Context, ch *amqp091.Channel, mes
 
 		outcome := "success"
 
-		err := r.handleMessage(ctx, message)
+		decompressed, err := decompress(message)
+		if err == nil {
+			err = r.handleMessage(ctx, decompressed)
+		}
 		if err != nil {
 			metrics.ConsumerProcessingFailedTotal.Inc()
 
@@ -72,7 +130,7 @@ func (r *RabbitMQ) processMessages(ctx context.Context, ch *amqp091.Channel, mes
 					logger.Field{Key: "error", Value: err.Error()},
 					logger.Field{Key: "message_id", Value: message.MessageId},
 				)
-				r.sendToDLQ(ctx, ch, message)
+				r.sendToDLQ(ctx, ch, message, err.Error())
 				outcome = "dlq"
 			} else if errors.Is(err, constant.ErrTransient) {
 				r.Log.WithContext(ctx).Error("Transient error processing message, sending to retry exchange",
@@ -95,22 +153,40 @@ func (r *RabbitMQ) processMessages(ctx context.Context, ch *amqp091.Channel, mes
 }
 
 func (r *RabbitMQ) handleMessage(ctx context.Context, message amqp091.Delivery) error {
-	var body map[string]any
-	if err := json.Unmarshal(message.Body, &body); err != nil {
-		return fmt.Errorf("%w: failed to unmarshal message body: %v",
-			constant.ErrPermanent, err,
+	msg, err := decodeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	payload, err := event.New(msg.EventKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", constant.ErrPermanent, err)
+	}
+
+	// Binary encodings are checked by their codec against the schema reference.
+	if codec.IsJSON(msg.ContentType) {
+		if err := r.SchemaRegistry.Validate(msg.EventKey, msg.Data); err != nil {
+			metrics.ConsumerInvalidMessagesTotal.WithLabelValues(msg.EventKey).Inc()
+			return fmt.Errorf("%w: %v", constant.ErrPermanent, err)
+		}
+	}
+
+	if err := r.Codecs.Decode(msg.ContentType, msg.SchemaRef, msg.Data, payload); err != nil {
+		metrics.ConsumerInvalidMessagesTotal.WithLabelValues(msg.EventKey).Inc()
+		return fmt.Errorf("%w: failed to decode %s message body: %v",
+			constant.ErrPermanent, msg.ContentType, err,
 		)
 	}
 
-	if message.MessageId == "" {
+	if msg.ID == "" {
 		err := fmt.Errorf("%w: message_id header missing or invalid",
 			constant.ErrPermanent,
 		)
 		return err
 	}
 
-	err := service.WithTransaction(ctx, r.DB, func(tx *gorm.DB) error {
-		return r.processMessageOnce(ctx, tx, message.MessageId, body)
+	err = service.WithTransaction(ctx, r.DB, func(tx *gorm.DB) error {
+		return r.processMessageOnce(ctx, tx, msg.ID, payload)
 	})
 	if err != nil {
 		return fmt.Errorf("%w: failed to process message: %v",
@@ -125,7 +201,7 @@ func (r *RabbitMQ) processMessageOnce(
 	ctx context.Context,
 	tx *gorm.DB,
 	messageID string,
-	body map[string]any,
+	payload any,
 ) error {
 	inserted, err := r.ProcessedMessageService.TryInsert(
 		ctx,
@@ -147,7 +223,7 @@ func (r *RabbitMQ) processMessageOnce(
 	// Business logic (demo)
 	r.Log.Info(
 		"Order email sent to customer",
-		logger.Field{Key: "payload", Value: body},
+		logger.Field{Key: "payload", Value: payload},
 	)
 
 	return nil
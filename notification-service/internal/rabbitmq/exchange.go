@@ -6,8 +6,9 @@ func (r *RabbitMQ) initExchanges(ch *amqp091.Channel) error {
 	exchanges := []struct {
 		Name string
 		Kind string
+		Args amqp091.Table
 	}{
-		{Name: r.Config.Exchange, Kind: "topic"},
+		{Name: r.Config.Exchange, Kind: "topic", Args: r.exchangeArgs()},
 		{Name: r.Config.DLX, Kind: "direct"},
 	}
 
@@ -19,7 +20,7 @@ func (r *RabbitMQ) initExchanges(ch *amqp091.Channel) error {
 			false,
 			false,
 			false,
-			nil,
+			exchange.Args,
 		)
 		if err != nil {
 			return err
@@ -28,3 +29,13 @@ func (r *RabbitMQ) initExchanges(ch *amqp091.Channel) error {
 
 	return nil
 }
+
+// exchangeArgs must match the order-service declaration of the events exchange,
+// otherwise the broker rejects the redeclaration.
+func (r *RabbitMQ) exchangeArgs() amqp091.Table {
+	if r.Config.AlternateExchange == "" {
+		return nil
+	}
+
+	return amqp091.Table{"alternate-exchange": r.Config.AlternateExchange}
+}
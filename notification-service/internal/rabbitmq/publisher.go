@@ -15,6 +15,10 @@ type PublishOpts struct {
 	Body       interface{}
 	Headers    amqp091.Table
 	MessageID  string
+	// ContentType defaults to application/json. Republished deliveries keep
+	// theirs, e.g. structured-mode CloudEvents.
+	ContentType     string
+	ContentEncoding string
 }
 
 func (r *RabbitMQ) Publish(ctx context.Context, opts *PublishOpts) error {
@@ -32,6 +36,11 @@ func (r *RabbitMQ) Publish(ctx context.Context, opts *PublishOpts) error {
 		}
 	}
 
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
 	err := opts.Ch.PublishWithContext(
 		ctx,
 		opts.Exchange,
@@ -39,10 +48,11 @@ func (r *RabbitMQ) Publish(ctx context.Context, opts *PublishOpts) error {
 		false,
 		false,
 		amqp091.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-			Headers:     opts.Headers,
-			MessageId:   opts.MessageID,
+			ContentType:     contentType,
+			ContentEncoding: opts.ContentEncoding,
+			Body:            body,
+			Headers:         opts.Headers,
+			MessageId:       opts.MessageID,
 		},
 	)
 	if err != nil {
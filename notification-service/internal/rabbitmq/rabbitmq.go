@@ -2,12 +2,15 @@ package rabbitmq
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/codec"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/config"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/database"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/schema"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/notification-service/internal/service"
 	"gorm.io/gorm"
 )
@@ -26,7 +29,12 @@ type RabbitMQ struct {
 	Log                     logger.Logger
 	ProcessedMessageService service.ProcessedMessageService
 	DB                      *gorm.DB
+	SchemaRegistry          *schema.Registry
+	Codecs                  *codec.Registry
 	RetryConfig             RetryConfig
+
+	mu      sync.RWMutex // guards Conn and closing
+	closing bool
 }
 
 type Opts struct {
@@ -34,6 +42,8 @@ type Opts struct {
 	Logger                  logger.Logger
 	ProcessedMessageService service.ProcessedMessageService
 	DB                      database.DatabaseService
+	SchemaRegistry          *schema.Registry
+	Codecs                  *codec.Registry
 }
 
 func NewRabbitMQ(ctx context.Context, opts *Opts) (RabbitMQService, error) {
@@ -42,6 +52,8 @@ func NewRabbitMQ(ctx context.Context, opts *Opts) (RabbitMQService, error) {
 		Log:                     opts.Logger,
 		ProcessedMessageService: opts.ProcessedMessageService,
 		DB:                      opts.DB.DB(),
+		SchemaRegistry:          opts.SchemaRegistry,
+		Codecs:                  opts.Codecs,
 		RetryConfig: RetryConfig{
 			Levels: []RetryLevel{
 				{"retry.30s", 30 * time.Second},
@@ -53,12 +65,22 @@ func NewRabbitMQ(ctx context.Context, opts *Opts) (RabbitMQService, error) {
 	if err := b.connect(ctx); err != nil {
 		return nil, err
 	}
+	if err := b.Consume(ctx); err != nil {
+		_ = b.Close()
+		return nil, err
+	}
+	go b.watch(ctx)
 
 	return b, nil
 }
 
 func (r *RabbitMQ) NewChannel() (*amqp091.Channel, error) {
-	c, err := r.Conn.Channel()
+	conn := r.conn()
+	if conn == nil {
+		return nil, amqp091.ErrClosed
+	}
+
+	c, err := conn.Channel()
 	if err != nil {
 		r.Log.Error("RabbitMQ channel error", logger.Field{Key: "error", Value: err.Error()})
 		return nil, err
@@ -21,6 +21,7 @@ type RetryLevel struct {
 
 const (
 	HeaderRetryCount = "x-retry-count"
+	HeaderDLQReason  = "x-dlq-reason"
 )
 
 func (r *RabbitMQ) retryMessage(
@@ -34,7 +35,7 @@ func (r *RabbitMQ) retryMessage(
 		r.Log.WithContext(ctx).Info("Max retry attempts reached, sending message to DLQ",
 			logger.Field{Key: "message_id", Value: message.MessageId},
 		)
-		return r.sendToDLQ(ctx, ch, message)
+		return r.sendToDLQ(ctx, ch, message, "retries exhausted")
 	}
 
 	metrics.ConsumerRetriesTotal.Inc()
@@ -52,25 +53,34 @@ func (r *RabbitMQ) retryMessage(
 	)
 
 	opts := &PublishOpts{
-		Ch:         ch,
-		Exchange:   r.Config.Exchange + "." + retry.Name,
-		RoutingKey: message.RoutingKey,
-		Body:       message.Body,
-		Headers:    headers,
-		MessageID:  message.MessageId,
+		Ch:              ch,
+		Exchange:        r.Config.Exchange + "." + retry.Name,
+		RoutingKey:      message.RoutingKey,
+		Body:            message.Body,
+		Headers:         headers,
+		MessageID:       message.MessageId,
+		ContentType:     message.ContentType,
+		ContentEncoding: message.ContentEncoding,
 	}
 
 	return r.Publish(ctx, opts)
 }
 
-func (r *RabbitMQ) sendToDLQ(ctx context.Context, ch *amqp091.Channel, message amqp091.Delivery) error {
+// sendToDLQ records why the message was dead-lettered in the x-dlq-reason header.
+func (r *RabbitMQ) sendToDLQ(ctx context.Context, ch *amqp091.Channel, message amqp091.Delivery, reason string) error {
+	headers := amqp091.Table{}
+	maps.Copy(headers, message.Headers)
+	headers[HeaderDLQReason] = reason
+
 	opts := &PublishOpts{
-		Ch:         ch,
-		Exchange:   r.Config.DLX,
-		RoutingKey: r.Config.DLQ,
-		Body:       message.Body,
-		Headers:    message.Headers,
-		MessageID:  message.MessageId,
+		Ch:              ch,
+		Exchange:        r.Config.DLX,
+		RoutingKey:      r.Config.DLQ,
+		Body:            message.Body,
+		Headers:         headers,
+		MessageID:       message.MessageId,
+		ContentType:     message.ContentType,
+		ContentEncoding: message.ContentEncoding,
 	}
 	if err := r.Publish(ctx, opts); err != nil {
 		metrics.ConsumerDLQPublishFailedTotal.Inc()
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/rabbitmq"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
+)
+
+const exportPageSize = 500
+
+// filterFlags are shared by every command that selects events.
+type filterFlags struct {
+	ids           string
+	status        string
+	eventKey      string
+	failureReason string
+	from          string
+	to            string
+}
+
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.ids, "id", "", "comma-separated event IDs")
+	fs.StringVar(&f.status, "status", "", "event status")
+	fs.StringVar(&f.eventKey, "event-key", "", "event key")
+	fs.StringVar(&f.failureReason, "failure-reason", "", "case-insensitive substring of the failure reason")
+	fs.StringVar(&f.from, "from", "", "created at or after (RFC 3339)")
+	fs.StringVar(&f.to, "to", "", "created before (RFC 3339)")
+}
+
+func (f *filterFlags) filter() (*service.OutboxEventFilter, error) {
+	filter := &service.OutboxEventFilter{
+		Status:        f.status,
+		EventKey:      f.eventKey,
+		FailureReason: f.failureReason,
+	}
+	if f.ids != "" {
+		filter.IDs = strings.Split(f.ids, ",")
+	}
+
+	var err error
+	if f.from != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, f.from); err != nil {
+			return nil, fmt.Errorf("invalid -from: %w", err)
+		}
+	}
+	if f.to != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, f.to); err != nil {
+			return nil, fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// actionFlags are shared by commands that change events.
+type actionFlags struct {
+	actor  string
+	reason string
+	dryRun bool
+}
+
+func (a *actionFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&a.actor, "actor", os.Getenv("USER"), "who is performing the action, recorded in the audit log")
+	fs.StringVar(&a.reason, "reason", "", "why the action is performed, recorded in the audit log (required)")
+	fs.BoolVar(&a.dryRun, "dry-run", false, "only report how many events would be affected")
+}
+
+func (a *actionFlags) action() (*service.AdminAction, error) {
+	if a.actor == "" || a.reason == "" {
+		return nil, errors.New("-actor and -reason are required")
+	}
+	return &service.AdminAction{Actor: a.actor, Reason: a.reason, DryRun: a.dryRun}, nil
+}
+
+func runStats(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	stats, err := a.outboxEventService.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return printJSON(os.Stdout, stats)
+	}
+
+	rows := make([][]string, 0, len(stats))
+	for _, s := range stats {
+		rows = append(rows, []string{s.Status, s.EventKey, s.Age, fmt.Sprint(s.Count)})
+	}
+	return printTable(os.Stdout, []string{"STATUS", "EVENT KEY", "AGE", "COUNT"}, rows)
+}
+
+func runList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	var ff filterFlags
+	ff.register(fs)
+	output := outputFlag(fs)
+	page := fs.Int("page", 1, "page number")
+	limit := fs.Int("limit", 50, "events per page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	events, total, err := a.outboxEventService.ListEvents(ctx, filter, *limit, (max(*page, 1)-1)*(*limit))
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return printJSON(os.Stdout, map[string]any{"events": events, "total": total})
+	}
+
+	if err := printEvents(os.Stdout, events); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d events\n", len(events), total)
+
+	return nil
+}
+
+func runShow(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: outboxctl show [flags] <event-id>")
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	event, err := a.outboxEventService.GetEvent(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return printJSON(os.Stdout, event)
+	}
+
+	payload, _ := json.Marshal(event.Payload)
+	rows := [][]string{
+		{"ID", event.ID},
+		{"EVENT KEY", event.EventKey},
+		{"AGGREGATE", fmt.Sprintf("%s #%d", event.AggregateID, event.Sequence)},
+		{"STATUS", event.Status},
+		{"RETRY COUNT", fmt.Sprint(event.RetryCount)},
+		{"NEXT RETRY AT", formatTime(event.NextRetryAt)},
+		{"LOCKED BY", event.LockedBy},
+		{"LOCKED AT", formatTime(event.LockedAt)},
+		{"FAILURE REASON", event.FailureReason},
+		{"FAILED AT", formatTime(event.FailedAt)},
+		{"TRACEPARENT", event.Traceparent},
+		{"CREATED AT", formatTime(event.CreatedAt)},
+		{"CONTENT TYPE", event.ContentType},
+		{"SCHEMA", event.SchemaRef},
+		{"ENCRYPTION", formatEncryption(event)},
+		{"PAYLOAD", string(payload)},
+	}
+	return printTable(os.Stdout, nil, rows)
+}
+
+func runRequeue(ctx context.Context, args []string) error {
+	return runAdminAction(ctx, "requeue", model.OutboxEventStatusFailed, args,
+		func(svc service.OutboxEventService) adminActionFunc { return svc.RequeueEvents })
+}
+
+func runCancel(ctx context.Context, args []string) error {
+	return runAdminAction(ctx, "cancel", model.OutboxEventStatusPending, args,
+		func(svc service.OutboxEventService) adminActionFunc { return svc.CancelEvents })
+}
+
+type adminActionFunc func(ctx context.Context, filter *service.OutboxEventFilter, action *service.AdminAction) (int64, error)
+
+func runAdminAction(
+	ctx context.Context,
+	name string,
+	fromStatus string,
+	args []string,
+	actionFn func(svc service.OutboxEventService) adminActionFunc,
+) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	var ff filterFlags
+	var af actionFlags
+	ff.register(fs)
+	af.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	action, err := af.action()
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	// A dry run goes through the same validation and filter as the real run.
+	affected, err := actionFn(a.outboxEventService)(ctx, filter, action)
+	if err != nil {
+		return err
+	}
+
+	if action.DryRun {
+		fmt.Printf("dry run: %s would affect %d %s events\n", name, affected, fromStatus)
+		return nil
+	}
+
+	fmt.Printf("%s: %d events affected\n", name, affected)
+	return nil
+}
+
+func runPurge(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", 0, "purge events created longer ago than this (required)")
+	status := fs.String("status", model.OutboxEventStatusPublished, "published, failed or cancelled")
+	archive := fs.Bool("archive", false, "move events into outbox_events_archive instead of deleting them")
+	batchSize := fs.Int("batch-size", 1000, "events removed per statement")
+	dryRun := fs.Bool("dry-run", false, "only report how many events would be purged")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *olderThan <= 0 {
+		return errors.New("-older-than is required")
+	}
+	switch *status {
+	case model.OutboxEventStatusPublished, model.OutboxEventStatusFailed, model.OutboxEventStatusCancelled:
+	default:
+		return fmt.Errorf("cannot purge %q events", *status)
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if *dryRun {
+		filter := &service.OutboxEventFilter{Status: *status, CreatedTo: time.Now().Add(-*olderThan)}
+		_, total, err := a.outboxEventService.ListEvents(ctx, filter, 1, 0)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("dry run: purge would remove %d %s events\n", total, *status)
+		return nil
+	}
+
+	var total int64
+	for ctx.Err() == nil {
+		count, err := a.outboxEventService.PurgeEvents(ctx, *status, *olderThan, *batchSize, *archive)
+		if err != nil {
+			return err
+		}
+		total += count
+		if count < int64(*batchSize) {
+			break
+		}
+	}
+
+	fmt.Printf("purge: %d %s events removed\n", total, *status)
+	return ctx.Err()
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var ff filterFlags
+	ff.register(fs)
+	out := fs.String("out", "", "file to write to instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	enc := json.NewEncoder(w)
+	var exported int64
+
+	for offset := 0; ; offset += exportPageSize {
+		events, _, err := a.outboxEventService.ListEvents(ctx, filter, exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := enc.Encode(event); err != nil {
+				return err
+			}
+		}
+		exported += int64(len(events))
+
+		if len(events) < exportPageSize {
+			break
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d events exported\n", exported)
+	return nil
+}
+
+func runRedrive(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("redrive", flag.ContinueOnError)
+	mode := fs.String("mode", "", "reinsert or republish (default OUTBOX_REDRIVE_MODE)")
+	rate := fs.Int("rate", -1, "messages per second, 0 for unlimited (default OUTBOX_REDRIVE_RATE)")
+	limit := fs.Int("limit", 0, "maximum number of messages to redrive, 0 for all")
+	rewrite := fs.String("rewrite", "", "routing key rewrites as old=new,... (default OUTBOX_REDRIVE_ROUTING_KEYS)")
+	output := outputFlag(fs)
+	dryRun := fs.Bool("dry-run", false, "only report how many messages are in the DLQ")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	outboxConfig := *a.cfg.Outbox
+	if *mode != "" {
+		outboxConfig.RedriveMode = *mode
+	}
+	if *rate >= 0 {
+		outboxConfig.RedriveRate = *rate
+	}
+	if *rewrite != "" {
+		outboxConfig.RedriveRoutingKeys = parseRewrites(*rewrite)
+	}
+
+	rmq, err := rabbitmq.NewRabbitMQ(ctx, &rabbitmq.Opts{
+		Config: a.cfg.AMQP,
+		Logger: a.log,
+	})
+	if err != nil {
+		return err
+	}
+	defer rmq.Close()
+
+	if *dryRun {
+		ch, err := rmq.NewChannel()
+		if err != nil {
+			return err
+		}
+		defer ch.Close()
+
+		q, err := ch.QueueDeclarePassive(a.cfg.AMQP.DLQ, true, false, false, false, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("dry run: %d messages in %s would be redriven\n", q.Messages, q.Name)
+		return nil
+	}
+
+	publisher, err := outbox.NewPublisher(rmq, a.cfg.AMQP, a.cfg.CloudEvents)
+	if err != nil {
+		return err
+	}
+
+	redriver := outbox.NewRedriver(&outbox.RedriverOpts{
+		Log:                a.log,
+		OutboxEventService: a.outboxEventService,
+		RabbitMQ:           rmq,
+		Publisher:          publisher,
+		Config:             &outboxConfig,
+		AMQPConfig:         a.cfg.AMQP,
+	})
+
+	result, err := redriver.Drain(ctx, *limit)
+	if *output == outputJSON {
+		if printErr := printJSON(os.Stdout, result); printErr != nil {
+			return printErr
+		}
+	} else {
+		fmt.Printf("redrive: %d redriven, %d duplicates, %d invalid\n", result.Redriven, result.Duplicates, result.Invalid)
+	}
+
+	return err
+}
+
+func parseRewrites(s string) map[string]string {
+	rewrites := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if from, to, ok := strings.Cut(pair, "="); ok {
+			rewrites[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+	}
+	return rewrites
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/encryption"
+)
+
+const keysUsage = `Usage: outboxctl keys <command> [flags]
+
+Commands:
+  init     create the keyring file with a first primary key
+  list     list keys and how many payloads each one protects
+  rotate   add a new primary key, new payloads are encrypted with it
+  rewrap   rewrap data keys with the primary key, -archive includes the archive
+  destroy  destroy a key, crypto-shredding every payload it protects
+
+The relay periodically rewraps the data keys of live events only. Archived
+payloads keep their key, so destroying it shreds them. Rewrap the archive
+with "rewrap -archive" first to keep archived payloads readable instead.
+`
+
+var keyCommands = map[string]command{
+	"init":    runKeysInit,
+	"list":    runKeysList,
+	"rotate":  runKeysRotate,
+	"rewrap":  runKeysRewrap,
+	"destroy": runKeysDestroy,
+}
+
+func runKeys(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, keysUsage)
+		return flag.ErrHelp
+	}
+
+	run, ok := keyCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown keys command %q\n\n%s", args[0], keysUsage)
+		return flag.ErrHelp
+	}
+
+	return run(ctx, args[1:])
+}
+
+// keyringFile returns OUTBOX_ENCRYPTION_KEYRING_FILE, which every keys
+// command needs.
+func keyringFile() (string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Encryption.KeyringFile == "" {
+		return "", errors.New("OUTBOX_ENCRYPTION_KEYRING_FILE is not set")
+	}
+
+	return cfg.Encryption.KeyringFile, nil
+}
+
+// tables returns the tables holding encrypted payloads, as the archive flag
+// of the outbox event service. Partitioned outboxes have no archive.
+func (a *app) tables() []bool {
+	if a.cfg.Outbox.PartitioningEnabled {
+		return []bool{false}
+	}
+	return []bool{false, true}
+}
+
+// stateOf describes a key as listed by "keys list".
+func stateOf(keyring *encryption.Keyring, id string) string {
+	switch {
+	case id == keyring.Primary():
+		return "primary"
+	case keyring.Destroying(id):
+		return "destroying"
+	case slices.Contains(keyring.IDs(), id):
+		return "active"
+	default:
+		return "destroyed"
+	}
+}
+
+func tableName(archive bool) string {
+	if archive {
+		return "outbox_events_archive"
+	}
+	return "outbox_events"
+}
+
+func runKeysInit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keys init", flag.ContinueOnError)
+	id := fs.String("id", "", "ID of the first key, e.g. the current month (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	path, err := keyringFile()
+	if err != nil {
+		return err
+	}
+	if err := encryption.NewKeyringFile(path, *id); err != nil {
+		return err
+	}
+
+	fmt.Printf("keys init: created %s with primary key %q\n", path, *id)
+	return nil
+}
+
+func runKeysList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keys list", flag.ContinueOnError)
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if a.cipher == nil {
+		return errors.New("OUTBOX_ENCRYPTION_KEYRING_FILE is not set")
+	}
+
+	type keyRow struct {
+		KeyID    string `json:"key_id"`
+		State    string `json:"state"`
+		Table    string `json:"table"`
+		Count    int64  `json:"count"`
+		Shredded int64  `json:"shredded"`
+	}
+
+	keyring := a.cipher.Keyring()
+	var rows []*keyRow
+	for _, archive := range a.tables() {
+		usage, err := a.outboxEventService.KeyUsage(ctx, archive)
+		if err != nil {
+			return err
+		}
+
+		seen := map[string]bool{}
+		for _, u := range usage {
+			seen[u.KeyID] = true
+			rows = append(rows, &keyRow{KeyID: u.KeyID, Table: tableName(archive), Count: u.Count, Shredded: u.Shredded})
+		}
+		for _, id := range keyring.IDs() {
+			if !seen[id] {
+				rows = append(rows, &keyRow{KeyID: id, Table: tableName(archive)})
+			}
+		}
+	}
+
+	for _, r := range rows {
+		r.State = stateOf(keyring, r.KeyID)
+	}
+
+	if *output == outputJSON {
+		return printJSON(os.Stdout, rows)
+	}
+
+	table := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		table = append(table, []string{r.KeyID, r.State, r.Table, fmt.Sprint(r.Count), fmt.Sprint(r.Shredded)})
+	}
+	return printTable(os.Stdout, []string{"KEY ID", "STATE", "TABLE", "PAYLOADS", "SHREDDED"}, table)
+}
+
+func runKeysRotate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keys rotate", flag.ContinueOnError)
+	id := fs.String("id", "", "ID of the new primary key (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	path, err := keyringFile()
+	if err != nil {
+		return err
+	}
+	keyring, err := encryption.LoadKeyring(path)
+	if err != nil {
+		return err
+	}
+
+	previous := keyring.Primary()
+	if err := keyring.Rotate(*id); err != nil {
+		return err
+	}
+
+	fmt.Printf("keys rotate: primary key is now %q, was %q\n", *id, previous)
+	fmt.Println("running relays pick it up within OUTBOX_ENCRYPTION_REWRAP_INTERVAL and rewrap the data keys of live events")
+	return nil
+}
+
+func runKeysRewrap(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keys rewrap", flag.ContinueOnError)
+	batchSize := fs.Int("batch-size", 500, "data keys rewrapped per transaction")
+	archive := fs.Bool("archive", false, "also rewrap archived payloads, which keeps them readable after their key is destroyed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batchSize < 1 {
+		return errors.New("-batch-size must be at least 1")
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if a.cipher == nil {
+		return errors.New("OUTBOX_ENCRYPTION_KEYRING_FILE is not set")
+	}
+
+	tables := []bool{false}
+	if *archive {
+		if a.cfg.Outbox.PartitioningEnabled {
+			return service.ErrNoArchive
+		}
+		tables = append(tables, true)
+	}
+
+	keyring := a.cipher.Keyring()
+	for _, archive := range tables {
+		var total int64
+		for ctx.Err() == nil {
+			// keys marked for destruction meanwhile are skipped from the next batch on
+			if err := keyring.Reload(); err != nil {
+				return err
+			}
+
+			count, err := a.outboxEventService.RewrapKeys(ctx, archive, keyring.Stale(), *batchSize, a.cipher.Rewrap)
+			if err != nil {
+				return err
+			}
+
+			total += count
+			if count < int64(*batchSize) {
+				break
+			}
+		}
+		fmt.Printf("keys rewrap: %d data keys in %s rewrapped with %q\n", total, tableName(archive), keyring.Primary())
+	}
+
+	return ctx.Err()
+}
+
+func runKeysDestroy(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keys destroy", flag.ContinueOnError)
+	id := fs.String("id", "", "ID of the key to destroy (required)")
+	confirm := fs.Bool("confirm", false, "confirm that payloads still protected by the key become unreadable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if a.cipher == nil {
+		return errors.New("OUTBOX_ENCRYPTION_KEYRING_FILE is not set")
+	}
+
+	if !*confirm {
+		for _, archive := range a.tables() {
+			usage, err := a.outboxEventService.KeyUsage(ctx, archive)
+			if err != nil {
+				return err
+			}
+			for _, u := range usage {
+				if u.KeyID == *id {
+					fmt.Printf("dry run: destroy would shred %d payloads in %s\n", u.Count-u.Shredded, tableName(archive))
+				}
+			}
+		}
+		fmt.Println("dry run: pass -confirm to destroy the key")
+		return nil
+	}
+
+	// Relays stop rewrapping the key's data keys once it is marked, so none of
+	// them escapes the shredding by moving to another key.
+	keyring := a.cipher.Keyring()
+	if err := keyring.MarkDestroying(*id); err != nil {
+		return err
+	}
+
+	// Dropping the data keys first keeps the payloads unreadable even if a
+	// copy of the keyring survived. A failed run can simply be repeated.
+	for _, archive := range a.tables() {
+		count, err := a.outboxEventService.ShredKey(ctx, archive, *id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("keys destroy: %d payloads in %s shredded\n", count, tableName(archive))
+	}
+
+	if err := keyring.Destroy(*id); err != nil {
+		return err
+	}
+	fmt.Printf("keys destroy: key %q removed from the keyring\n", *id)
+
+	return nil
+}
@@ -0,0 +1,140 @@
+// outboxctl operates the outbox directly against the database, for when the
+// order-service HTTP API is not reachable.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/schema"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/encryption"
+)
+
+const usage = `Usage: outboxctl <command> [flags]
+
+Commands:
+  stats      counts by status, event_key and age
+  list       list events matching filters
+  show       show a single event
+  requeue    move failed events back to pending
+  cancel     cancel pending events
+  purge      delete or archive old events
+  export     write events matching filters as JSON lines
+  redrive    take dead-lettered events off the DLQ
+  keys       manage the payload encryption keyring
+
+Run "outboxctl <command> -h" for the flags of a command.
+`
+
+type command func(ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"stats":   runStats,
+	"list":    runList,
+	"show":    runShow,
+	"requeue": runRequeue,
+	"cancel":  runCancel,
+	"purge":   runPurge,
+	"export":  runExport,
+	"redrive": runRedrive,
+	"keys":    runKeys,
+}
+
+type app struct {
+	cfg                *config.Config
+	log                logger.Logger
+	db                 database.DatabaseService
+	cipher             *encryption.Cipher // nil when payloads are stored in plaintext
+	outboxEventService service.OutboxEventService
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[2:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "outboxctl %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// loadConfig reads .env, or the file named by OUTBOXCTL_ENV_FILE.
+func loadConfig() (*config.Config, error) {
+	envFile := os.Getenv("OUTBOXCTL_ENV_FILE")
+	if envFile == "" {
+		envFile = ".env"
+	}
+
+	return config.NewConfig(envFile)
+}
+
+// newApp connects to the database configured in .env, or in the file named by
+// OUTBOXCTL_ENV_FILE.
+func newApp() (*app, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Events re-created by redrive are validated and encrypted like any other.
+	cipher, err := outbox.NewCipher(cfg.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	schemaRegistry, err := schema.NewRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep stdout clean for table and JSON output.
+	log := logger.NewZerologLogger("warn", os.Stderr)
+
+	db, err := database.NewDatabase(&database.Opts{
+		Config: cfg.Database,
+		Log:    log,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &app{
+		cfg:    cfg,
+		log:    log,
+		db:     db,
+		cipher: cipher,
+		outboxEventService: service.NewOutboxEventService(&service.OutboxEventServiceOpts{
+			DB:     db,
+			Log:    log,
+			Store:  outbox.NewStore(db.DB(), cfg.Outbox, cipher, schemaRegistry),
+			Config: cfg.Outbox,
+		}),
+	}, nil
+}
+
+func (a *app) Close() error {
+	return a.db.Close()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("o", outputTable, "output format: table or json")
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if header != nil {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func printEvents(w io.Writer, events []*model.OutboxEvent) error {
+	rows := make([][]string, 0, len(events))
+	for _, e := range events {
+		rows = append(rows, []string{
+			e.ID,
+			e.EventKey,
+			e.Status,
+			fmt.Sprint(e.RetryCount),
+			formatTime(e.CreatedAt),
+			e.FailureReason,
+		})
+	}
+
+	return printTable(w, []string{"ID", "EVENT KEY", "STATUS", "RETRIES", "CREATED AT", "FAILURE REASON"}, rows)
+}
+
+// formatEncryption describes how a payload is stored, without decrypting it.
+func formatEncryption(event *model.OutboxEvent) string {
+	switch {
+	case event.KeyID == "":
+		return "-"
+	case event.WrappedKey == nil:
+		return fmt.Sprintf("%s, key %s (shredded)", event.EncryptionScope, event.KeyID)
+	default:
+		return fmt.Sprintf("%s, key %s", event.EncryptionScope, event.KeyID)
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
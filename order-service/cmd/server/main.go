@@ -12,11 +12,15 @@ import (
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database"
 	httpserver "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/http"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/nats"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/tracing"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/outbox"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/rabbitmq"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/schema"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/memorybroker"
 )
 
 func main() {
@@ -46,44 +50,150 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
-	rmq, err := rabbitmq.NewRabbitMQ(ctx, &rabbitmq.Opts{
-		Config: cfg.AMQP,
-		Logger: log,
-	})
+	checks := map[string]service.DependencyHealthCheck{
+		"database": func(ctx context.Context) error {
+			return db.Health(ctx)
+		},
+	}
+
+	// Only the brokers of configured transports are connected.
+	var (
+		rmq          rabbitmq.RabbitMQService
+		natsClient   nats.NATSService
+		memoryBroker *memorybroker.Broker
+	)
+	publishers := map[string]outboxlib.Publisher{}
+
+	if outbox.UsesTransport(cfg.Outbox, outbox.TransportAMQP) {
+		rmq, err = rabbitmq.NewRabbitMQ(ctx, &rabbitmq.Opts{
+			Config: cfg.AMQP,
+			Logger: log,
+		})
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		publishers[outbox.TransportAMQP], err = outbox.NewPublisher(rmq, cfg.AMQP, cfg.CloudEvents)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		checks["rabbitmq"] = func(ctx context.Context) error {
+			return rmq.Health()
+		}
+	}
+	if outbox.UsesTransport(cfg.Outbox, outbox.TransportNATS) {
+		natsClient, err = nats.NewNATS(ctx, &nats.Opts{
+			Config: cfg.NATS,
+			Logger: log,
+		})
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		publishers[outbox.TransportNATS] = outbox.NewNATSPublisher(natsClient, cfg.NATS)
+		checks["nats"] = func(ctx context.Context) error {
+			return natsClient.Health()
+		}
+	}
+	if outbox.UsesTransport(cfg.Outbox, outbox.TransportMemory) {
+		memoryBroker = outbox.NewMemoryBroker(log, cfg.AMQP)
+		publishers[outbox.TransportMemory] = memoryBroker
+	}
+
+	var webhookSubscriptionService service.WebhookSubscriptionService
+	if outbox.UsesTransport(cfg.Outbox, outbox.TransportWebhook) {
+		// Subscriptions are managed through the admin API, which is only served with tokens.
+		if len(cfg.Admin.Tokens) == 0 {
+			log.Fatal("the webhook transport requires ADMIN_API_TOKENS to manage its subscriptions")
+		}
+		webhookSubscriptionService = service.NewWebhookSubscriptionService(&service.WebhookSubscriptionServiceOpts{
+			DB:     db,
+			Log:    log,
+			Config: cfg.Webhook,
+		})
+		// Failed deliveries are dead-lettered through the first broker available.
+		var deadLetter outboxlib.Publisher
+		for _, t := range []string{outbox.TransportAMQP, outbox.TransportNATS, outbox.TransportMemory} {
+			if p, ok := publishers[t]; ok {
+				deadLetter = p
+				break
+			}
+		}
+		publishers[outbox.TransportWebhook] = outbox.NewWebhookPublisher(webhookSubscriptionService, cfg.Webhook, deadLetter)
+	}
+
+	// Payloads are encrypted on insert and only decrypted by the relay.
+	cipher, err := outbox.NewCipher(cfg.Encryption)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	schemaRegistry, err := schema.NewRegistry()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	outboxStore := outbox.NewStore(db.DB(), cfg.Outbox, cipher, schemaRegistry)
+
+	retryPolicies, err := outbox.NewRetryPolicies(cfg.Outbox)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
 	outboxEventService := service.NewOutboxEventService(&service.OutboxEventServiceOpts{
-		DB:  db,
-		Log: log,
+		DB:     db,
+		Log:    log,
+		Store:  outboxStore,
+		Config: cfg.Outbox,
 	})
+
+	// Fanout transports record each destination's confirm in outbox_deliveries.
+	outboxPublisher, err := outbox.NewTransportPublisher(cfg.Outbox, publishers, outboxEventService)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
 	orderService := service.NewOrderService(&service.OrderServiceOpts{
-		DB:                 db,
+		DB:          db,
+		Log:         log,
+		OutboxStore: outboxStore,
+	})
+
+	relay, err := outbox.NewOutbox(ctx, &outbox.Opts{
 		Log:                log,
 		OutboxEventService: outboxEventService,
+		Store:              outboxStore,
+		Publisher:          outboxPublisher,
+		Cipher:             cipher,
+		RetryPolicies:      retryPolicies,
+		Config:             cfg.Outbox,
+		DatabaseConfig:     cfg.Database,
+		EncryptionConfig:   cfg.Encryption,
 	})
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if cfg.Outbox.RedriveEnabled && rmq != nil {
+		redriver := outbox.NewRedriver(&outbox.RedriverOpts{
+			Log:                log,
+			OutboxEventService: outboxEventService,
+			RabbitMQ:           rmq,
+			Publisher:          outboxPublisher,
+			Config:             cfg.Outbox,
+			AMQPConfig:         cfg.AMQP,
+		})
+		go redriver.Run(ctx)
+	}
+
 	healthService := service.NewHealthService(&service.HealthServiceOpts{
-		Checks: map[string]service.DependencyHealthCheck{
-			"rabbitmq": func(ctx context.Context) error {
-				return rmq.Health()
-			},
-			"database": func(ctx context.Context) error {
-				return db.Health(ctx)
+		Checks: checks,
+		Details: map[string]service.HealthDetail{
+			"outbox_relay": func(ctx context.Context) string {
+				return relay.Role()
 			},
 		},
 	})
 
-	outbox.NewOutbox(ctx, &outbox.Opts{
-		DB:                 db,
-		Log:                log,
-		OutboxEventService: outboxEventService,
-		RabbitMQ:           rmq,
-		Config:             cfg.Outbox,
-		AMQPConfig:         cfg.AMQP,
-	})
-
-	metricsService := metrics.NewMetricsService(cfg.Metrics, &metrics.OutboxEventMetrics{})
+	metricsService := metrics.NewMetricsService(cfg.Metrics, &metrics.OutboxEventMetrics{}, &metrics.RabbitMQMetrics{}, &metrics.NATSMetrics{})
 
 	httpServer := httpserver.NewServer(cfg.HTTPServer.URL, &httpserver.Opts{
 		Config:         cfg,
@@ -91,6 +201,9 @@ func main() {
 		OrderService:   orderService,
 		MetricsService: metricsService,
 		HealthService:  healthService,
+
+		OutboxEventService:         outboxEventService,
+		WebhookSubscriptionService: webhookSubscriptionService,
 	})
 	go func() {
 		err = httpServer.Serve()
@@ -105,8 +218,25 @@ func main() {
 
 	healthService.SetReady(false)
 
-	if err := rmq.Close(); err != nil {
-		log.Error("failed to close rabbitmq client", logger.Field{Key: "error", Value: err.Error()})
+	// The relay still needs the broker and the database while it drains.
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), cfg.Outbox.ShutdownTimeout)
+	if err := relay.Stop(stopCtx); err != nil {
+		log.Error("failed to drain outbox relay", logger.Field{Key: "error", Value: err.Error()})
+	}
+	cancelStop()
+
+	if memoryBroker != nil {
+		_ = memoryBroker.Close()
+	}
+	if rmq != nil {
+		if err := rmq.Close(); err != nil {
+			log.Error("failed to close rabbitmq client", logger.Field{Key: "error", Value: err.Error()})
+		}
+	}
+	if natsClient != nil {
+		if err := natsClient.Close(); err != nil {
+			log.Error("failed to close nats client", logger.Field{Key: "error", Value: err.Error()})
+		}
 	}
 	if err := db.Close(); err != nil {
 		log.Error("failed to close database client", logger.Field{Key: "error", Value: err.Error()})
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// OutboxAdminAudit records a manual action taken on outbox events.
+type OutboxAdminAudit struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Actor     string    `gorm:"not null" json:"actor"`
+	Action    string    `gorm:"not null" json:"action"`
+	Reason    string    `gorm:"not null" json:"reason"`
+	Filter    JSONB     `gorm:"type:jsonb;not null" json:"filter"`
+	Affected  int64     `json:"affected"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (OutboxAdminAudit) TableName() string {
+	return "outbox_admin_audit"
+}
+
+var (
+	OutboxAdminActionRequeue = "requeue"
+	OutboxAdminActionCancel  = "cancel"
+)
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// OutboxDelivery records that a destination of a fanout transport confirmed an
+// event, so retries caused by other destinations skip it.
+type OutboxDelivery struct {
+	EventID     string    `gorm:"primaryKey"`
+	Destination string    `gorm:"primaryKey"`
+	DeliveredAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (OutboxDelivery) TableName() string {
+	return "outbox_deliveries"
+}
@@ -8,18 +8,36 @@ import (
 )
 
 type OutboxEvent struct {
-	ID            string    `gorm:"primaryKey" json:"id"`
-	EventKey      string    `gorm:"not null" json:"event_key"`
-	Payload       JSONB     `gorm:"type:jsonb;not null" json:"payload"`
-	Status        string    `gorm:"not null" json:"status"`
-	RetryCount    int       `json:"retry_count"`
-	NextRetryAt   time.Time `json:"next_retry_at"`
-	LockedAt      time.Time `json:"locked_at"`
-	LockedBy      string    `json:"locked_by"`
-	Traceparent   string    `json:"traceparent"` // Otel traceparent header
-	FailureReason string    `json:"failure_reason"`
-	FailedAt      time.Time `json:"failed_at"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID              string    `gorm:"primaryKey" json:"id"`
+	EventKey        string    `gorm:"not null" json:"event_key"`
+	AggregateID     string    `json:"aggregate_id"`
+	Sequence        int64     `json:"sequence"` // Position within the aggregate, assigned on insert
+	Payload         Payload   `gorm:"type:bytea;not null" json:"payload"`
+	ContentType     string    `gorm:"not null" json:"content_type"`
+	SchemaRef       string    `json:"schema_ref"`
+	KeyID           string    `json:"key_id"` // Keyring key of an encrypted payload, empty for plaintext
+	WrappedKey      []byte    `json:"-"`
+	EncryptionScope string    `json:"encryption_scope"`
+	Status          string    `gorm:"not null" json:"status"`
+	RetryCount      int       `json:"retry_count"`
+	NextRetryAt     time.Time `json:"next_retry_at"`
+	LockedAt        time.Time `json:"locked_at"`
+	LockedBy        string    `json:"locked_by"`
+	Traceparent     string    `json:"traceparent"` // Otel traceparent header
+	FailureReason   string    `json:"failure_reason"`
+	FailedAt        time.Time `json:"failed_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Payload is stored as opaque bytes. JSON payloads are rendered as JSON,
+// anything else as a base64 string.
+type Payload []byte
+
+func (p Payload) MarshalJSON() ([]byte, error) {
+	if json.Valid(p) {
+		return p, nil
+	}
+	return json.Marshal([]byte(p))
 }
 
 type JSONB map[string]interface{}
@@ -44,4 +62,5 @@ var (
 	OutboxEventStatusInProgress = "in_progress"
 	OutboxEventStatusPublished  = "published"
 	OutboxEventStatusFailed     = "failed"
+	OutboxEventStatusCancelled  = "cancelled"
 )
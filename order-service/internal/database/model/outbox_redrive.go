@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// OutboxRedrive records that a dead-lettered event was taken off the DLQ.
+// Redrives are idempotent per failure, keyed by event ID and the time it was
+// dead-lettered: a redelivered DLQ message is never redriven twice, while an
+// event that fails again after a redrive can be redriven again.
+type OutboxRedrive struct {
+	EventID    string    `gorm:"primaryKey" json:"event_id"`
+	FailedAt   time.Time `gorm:"primaryKey" json:"failed_at"`
+	Mode       string    `gorm:"not null" json:"mode"`
+	RoutingKey string    `gorm:"not null" json:"routing_key"`
+	RedrivenAt time.Time `gorm:"autoCreateTime" json:"redriven_at"`
+}
+
+func (OutboxRedrive) TableName() string {
+	return "outbox_redrives"
+}
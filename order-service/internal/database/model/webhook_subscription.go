@@ -0,0 +1,48 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// WebhookSubscription is an HTTP endpoint that receives the events matching
+// its event keys.
+type WebhookSubscription struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	URL         string     `gorm:"not null" json:"url"`
+	Secret      string     `gorm:"not null" json:"-"`
+	EventKeys   StringList `gorm:"type:jsonb;not null" json:"event_keys"` // exact keys or "prefix.*" patterns
+	TimeoutMS   int        `json:"timeout_ms"`                            // zero uses WEBHOOK_DEFAULT_TIMEOUT
+	RetryPolicy string     `json:"retry_policy"`                          // kind:base:maxDelay:maxAttempts, empty uses the event key's policy
+	Active      bool       `gorm:"not null" json:"active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// WebhookDelivery records that an endpoint received an event, so retries
+// caused by other endpoints skip it.
+type WebhookDelivery struct {
+	EventID        string    `gorm:"primaryKey"`
+	SubscriptionID string    `gorm:"primaryKey"`
+	DeliveredAt    time.Time `gorm:"autoCreateTime"`
+}
+
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, l)
+}
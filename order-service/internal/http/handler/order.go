@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/schema"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
 )
 
@@ -31,6 +33,10 @@ func (o *OrderHandler) Create(c *gin.Context) {
 		ProductID: req.ProductID,
 		Quantity:  req.Quantity,
 	})
+	if errors.Is(err, schema.ErrInvalidPayload) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		o.Log.Error("Created order failed", logger.Field{Key: "error", Value: err.Error()})
 
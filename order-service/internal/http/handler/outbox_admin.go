@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
+	"gorm.io/gorm"
+)
+
+const (
+	adminActorKey       = "admin_actor"
+	defaultPageSize     = 50
+	maxPageSize         = 500
+	maxPage             = 10000 // keeps the offset well within an int
+	adminFilterTimeSpec = time.RFC3339
+)
+
+type OutboxAdminHandlerOpts struct {
+	OutboxEventService service.OutboxEventService
+	Logger             logger.Logger
+	Tokens             map[string]string // actor => bearer token
+}
+
+type OutboxAdminHandler struct {
+	outboxEventService service.OutboxEventService
+	logger             logger.Logger
+	tokens             map[string]string
+}
+
+type AdminFilterRequest struct {
+	Status        string `json:"status" form:"status"`
+	EventKey      string `json:"event_key" form:"event_key"`
+	FailureReason string `json:"failure_reason" form:"failure_reason"`
+	CreatedFrom   string `json:"created_from" form:"created_from"` // RFC 3339
+	CreatedTo     string `json:"created_to" form:"created_to"`     // RFC 3339
+}
+
+type AdminActionRequest struct {
+	Reason string             `json:"reason" binding:"required"`
+	Filter AdminFilterRequest `json:"filter"`
+}
+
+func NewOutboxAdminHandler(opts *OutboxAdminHandlerOpts) *OutboxAdminHandler {
+	return &OutboxAdminHandler{
+		outboxEventService: opts.OutboxEventService,
+		logger:             opts.Logger,
+		tokens:             opts.Tokens,
+	}
+}
+
+// Authenticate requires one of the admin bearer tokens. The actor recorded in
+// the audit log is the owner of the token, never something the caller claims.
+func (h *OutboxAdminHandler) Authenticate(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	actor := ""
+	for name, expected := range h.tokens {
+		// Every token is compared, so timing does not tell which one matched.
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 && expected != "" {
+			actor = name
+		}
+	}
+	if !ok || actor == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+		return
+	}
+
+	c.Set(adminActorKey, actor)
+	c.Next()
+}
+
+func (h *OutboxAdminHandler) List(c *gin.Context) {
+	var req AdminFilterRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := req.toFilter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page := max(queryInt(c, "page", 1), 1)
+	if page > maxPage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("page must not exceed %d, narrow the filter instead", maxPage)})
+		return
+	}
+	pageSize := min(max(queryInt(c, "page_size", defaultPageSize), 1), maxPageSize)
+
+	events, total, err := h.outboxEventService.ListEvents(c.Request.Context(), filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.logger.Error("List outbox events failed", logger.Field{Key: "error", Value: err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":    events,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+}
+
+func (h *OutboxAdminHandler) Get(c *gin.Context) {
+	event, err := h.outboxEventService.GetEvent(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Get outbox event failed", logger.Field{Key: "error", Value: err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
+
+func (h *OutboxAdminHandler) Requeue(c *gin.Context) {
+	h.act(c, model.OutboxAdminActionRequeue, h.outboxEventService.RequeueEvents)
+}
+
+func (h *OutboxAdminHandler) Cancel(c *gin.Context) {
+	h.act(c, model.OutboxAdminActionCancel, h.outboxEventService.CancelEvents)
+}
+
+type adminActionFunc func(ctx context.Context, filter *service.OutboxEventFilter, action *service.AdminAction) (int64, error)
+
+// act runs a bulk action for /events/{action} or a single-event action when
+// the route has an :id parameter.
+func (h *OutboxAdminHandler) act(c *gin.Context, name string, fn adminActionFunc) {
+	var req AdminActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := req.Filter.toFilter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if id := c.Param("id"); id != "" {
+		filter = &service.OutboxEventFilter{IDs: []string{id}}
+	}
+
+	action := &service.AdminAction{Actor: c.GetString(adminActorKey), Reason: req.Reason}
+
+	affected, err := fn(c.Request.Context(), filter, action)
+	if errors.Is(err, service.ErrEmptyFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Outbox admin action failed",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "action", Value: name},
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	metrics.OutboxAdminEventsTotal.WithLabelValues(name).Add(float64(affected))
+	h.logger.Info("Outbox admin action applied",
+		logger.Field{Key: "action", Value: name},
+		logger.Field{Key: "actor", Value: action.Actor},
+		logger.Field{Key: "reason", Value: action.Reason},
+		logger.Field{Key: "affected", Value: affected},
+	)
+
+	c.JSON(http.StatusOK, gin.H{"affected": affected})
+}
+
+func (r *AdminFilterRequest) toFilter() (*service.OutboxEventFilter, error) {
+	filter := &service.OutboxEventFilter{
+		Status:        r.Status,
+		EventKey:      r.EventKey,
+		FailureReason: r.FailureReason,
+	}
+
+	var err error
+	if r.CreatedFrom != "" {
+		if filter.CreatedFrom, err = time.Parse(adminFilterTimeSpec, r.CreatedFrom); err != nil {
+			return nil, errors.New("created_from must be an RFC 3339 timestamp")
+		}
+	}
+	if r.CreatedTo != "" {
+		if filter.CreatedTo, err = time.Parse(adminFilterTimeSpec, r.CreatedTo); err != nil {
+			return nil, errors.New("created_to must be an RFC 3339 timestamp")
+		}
+	}
+
+	return filter, nil
+}
+
+func queryInt(c *gin.Context, key string, defaultVal int) int {
+	if val, err := strconv.Atoi(c.Query(key)); err == nil {
+		return val
+	}
+
+	return defaultVal
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
+	"gorm.io/gorm"
+)
+
+type WebhookSubscriptionHandlerOpts struct {
+	WebhookSubscriptionService service.WebhookSubscriptionService
+	Logger                     logger.Logger
+}
+
+type WebhookSubscriptionHandler struct {
+	webhookSubscriptionService service.WebhookSubscriptionService
+	logger                     logger.Logger
+}
+
+type WebhookSubscriptionRequest struct {
+	URL         *string  `json:"url"`
+	Secret      *string  `json:"secret"`
+	EventKeys   []string `json:"event_keys"`
+	TimeoutMS   *int     `json:"timeout_ms"`
+	RetryPolicy *string  `json:"retry_policy"` // kind:base:maxDelay:maxAttempts
+	Active      *bool    `json:"active"`
+}
+
+func NewWebhookSubscriptionHandler(opts *WebhookSubscriptionHandlerOpts) *WebhookSubscriptionHandler {
+	return &WebhookSubscriptionHandler{
+		webhookSubscriptionService: opts.WebhookSubscriptionService,
+		logger:                     opts.Logger,
+	}
+}
+
+func (h *WebhookSubscriptionHandler) List(c *gin.Context) {
+	subscriptions, err := h.webhookSubscriptionService.List(c.Request.Context())
+	if err != nil {
+		h.fail(c, "List webhook subscriptions failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+func (h *WebhookSubscriptionHandler) Get(c *gin.Context) {
+	subscription, err := h.webhookSubscriptionService.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, "Get webhook subscription failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscription": subscription})
+}
+
+// Create is the only response that includes the signing secret.
+func (h *WebhookSubscriptionHandler) Create(c *gin.Context) {
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookSubscriptionService.Create(c.Request.Context(), req.toInput())
+	if err != nil {
+		h.fail(c, "Create webhook subscription failed", err)
+		return
+	}
+
+	h.audit(c, "create", subscription.ID)
+	c.JSON(http.StatusCreated, gin.H{"subscription": subscription, "secret": subscription.Secret})
+}
+
+func (h *WebhookSubscriptionHandler) Update(c *gin.Context) {
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookSubscriptionService.Update(c.Request.Context(), c.Param("id"), req.toInput())
+	if err != nil {
+		h.fail(c, "Update webhook subscription failed", err)
+		return
+	}
+
+	h.audit(c, "update", subscription.ID)
+	c.JSON(http.StatusOK, gin.H{"subscription": subscription})
+}
+
+func (h *WebhookSubscriptionHandler) Delete(c *gin.Context) {
+	if err := h.webhookSubscriptionService.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, "Delete webhook subscription failed", err)
+		return
+	}
+
+	h.audit(c, "delete", c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookSubscriptionHandler) fail(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+	case errors.Is(err, service.ErrInvalidSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, logger.Field{Key: "error", Value: err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *WebhookSubscriptionHandler) audit(c *gin.Context, action, id string) {
+	h.logger.Info("Webhook subscription changed",
+		logger.Field{Key: "action", Value: action},
+		logger.Field{Key: "actor", Value: c.GetString(adminActorKey)},
+		logger.Field{Key: "subscription_id", Value: id},
+	)
+}
+
+func (r *WebhookSubscriptionRequest) toInput() *service.WebhookSubscriptionInput {
+	return &service.WebhookSubscriptionInput{
+		URL:         r.URL,
+		Secret:      r.Secret,
+		EventKeys:   r.EventKeys,
+		TimeoutMS:   r.TimeoutMS,
+		RetryPolicy: r.RetryPolicy,
+		Active:      r.Active,
+	}
+}
@@ -24,6 +24,9 @@ type Opts struct {
 	MetricsService metrics.MetricsService
 	Config         *config.Config
 	HealthService  service.HealthService
+
+	OutboxEventService         service.OutboxEventService
+	WebhookSubscriptionService service.WebhookSubscriptionService
 }
 
 func NewServer(url string, opts *Opts) *HTTPServer {
@@ -47,6 +50,38 @@ func NewServer(url string, opts *Opts) *HTTPServer {
 	r.GET("/livez", healthHandler.Livez)
 	r.GET("/readyz", healthHandler.Readyz)
 
+	// The admin API, webhook subscriptions included, is only served with tokens
+	// configured. The server refuses to start the webhook transport without them.
+	if len(opts.Config.Admin.Tokens) > 0 {
+		adminHandler := handler.NewOutboxAdminHandler(&handler.OutboxAdminHandlerOpts{
+			OutboxEventService: opts.OutboxEventService,
+			Logger:             opts.Log,
+			Tokens:             opts.Config.Admin.Tokens,
+		})
+
+		admin := r.Group("/admin/outbox", adminHandler.Authenticate)
+		admin.GET("/events", adminHandler.List)
+		admin.GET("/events/:id", adminHandler.Get)
+		admin.POST("/events/requeue", adminHandler.Requeue)
+		admin.POST("/events/cancel", adminHandler.Cancel)
+		admin.POST("/events/:id/requeue", adminHandler.Requeue)
+		admin.POST("/events/:id/cancel", adminHandler.Cancel)
+
+		if opts.WebhookSubscriptionService != nil {
+			webhookHandler := handler.NewWebhookSubscriptionHandler(&handler.WebhookSubscriptionHandlerOpts{
+				WebhookSubscriptionService: opts.WebhookSubscriptionService,
+				Logger:                     opts.Log,
+			})
+
+			webhooks := r.Group("/admin/webhooks", adminHandler.Authenticate)
+			webhooks.GET("/subscriptions", webhookHandler.List)
+			webhooks.GET("/subscriptions/:id", webhookHandler.Get)
+			webhooks.POST("/subscriptions", webhookHandler.Create)
+			webhooks.PATCH("/subscriptions/:id", webhookHandler.Update)
+			webhooks.DELETE("/subscriptions/:id", webhookHandler.Delete)
+		}
+	}
+
 	return &HTTPServer{
 		URL: url,
 		Server: &http.Server{
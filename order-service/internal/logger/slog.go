@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger returns a *slog.Logger writing to l, for libraries that log
+// through log/slog.
+func NewSlogLogger(l Logger) *slog.Logger {
+	return slog.New(&slogHandler{log: l})
+}
+
+type slogHandler struct {
+	log   Logger
+	attrs []Field
+	group string
+}
+
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	// zerolog filters by its global level
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]Field, 0, len(h.attrs)+record.NumAttrs())
+	fields = append(fields, h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = append(fields, h.field(attr))
+		return true
+	})
+
+	l := h.log.WithContext(ctx)
+	switch {
+	case record.Level >= slog.LevelError:
+		l.Error(record.Message, fields...)
+	case record.Level >= slog.LevelWarn:
+		l.Warn(record.Message, fields...)
+	case record.Level >= slog.LevelInfo:
+		l.Info(record.Message, fields...)
+	default:
+		l.Debug(record.Message, fields...)
+	}
+
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append([]Field{}, h.attrs...)
+	for _, attr := range attrs {
+		next.attrs = append(next.attrs, h.field(attr))
+	}
+	return &next
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	if h.group != "" {
+		name = h.group + "." + name
+	}
+	next.group = name
+	return &next
+}
+
+func (h *slogHandler) field(attr slog.Attr) Field {
+	key := attr.Key
+	if h.group != "" {
+		key = h.group + "." + key
+	}
+	return Field{Key: key, Value: attr.Value.Resolve().Any()}
+}
@@ -0,0 +1,116 @@
+package nats
+
+import (
+	"context"
+	"errors"
+
+	natsio "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+)
+
+type NATSService interface {
+	Health() error
+	Close() error
+	JetStream() jetstream.JetStream
+}
+
+type NATS struct {
+	Config *config.NATS
+	Conn   *natsio.Conn
+	JS     jetstream.JetStream
+	Log    logger.Logger
+}
+
+type Opts struct {
+	Config *config.NATS
+	Logger logger.Logger
+}
+
+// NewNATS connects and declares the outbox stream. The client reconnects on
+// its own, publishes made while disconnected are buffered.
+func NewNATS(ctx context.Context, opts *Opts) (NATSService, error) {
+	n := &NATS{
+		Config: opts.Config,
+		Log:    opts.Logger,
+	}
+
+	conn, err := natsio.Connect(n.Config.URL,
+		natsio.MaxReconnects(-1),
+		natsio.DisconnectErrHandler(func(_ *natsio.Conn, err error) {
+			metrics.NATSConnected.Set(0)
+			reason := "connection closed"
+			if err != nil {
+				reason = err.Error()
+			}
+			n.Log.Warn("NATS connection lost, reconnecting", logger.Field{Key: "error", Value: reason})
+		}),
+		natsio.ReconnectHandler(func(*natsio.Conn) {
+			metrics.NATSConnected.Set(1)
+			metrics.NATSReconnectsTotal.Inc()
+			n.Log.Info("NATS reconnected")
+		}),
+	)
+	if err != nil {
+		n.Log.Error("NATS connection error", logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+	n.Conn = conn
+
+	n.JS, err = jetstream.New(conn, jetstream.WithPublishAsyncTimeout(n.Config.PublishTimeout))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := n.declareStream(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	metrics.NATSConnected.Set(1)
+	n.Log.Info("NATS connected", logger.Field{Key: "stream", Value: n.Config.Stream})
+
+	return n, nil
+}
+
+func (n *NATS) JetStream() jetstream.JetStream {
+	return n.JS
+}
+
+func (n *NATS) Health() error {
+	if !n.Conn.IsConnected() {
+		return errors.New("NATS healthcheck failed")
+	}
+
+	return nil
+}
+
+func (n *NATS) Close() error {
+	metrics.NATSConnected.Set(0)
+
+	// Drain flushes buffered publishes before closing.
+	return n.Conn.Drain()
+}
+
+// declareStream captures every event subject and the dead-letter subject.
+// The duplicate window is what makes Nats-Msg-Id dedupe republished events.
+func (n *NATS) declareStream(ctx context.Context) error {
+	subjects := []string{n.Config.SubjectPrefix + ">"}
+	if n.Config.DeadLetterSubject != "" {
+		subjects = append(subjects, n.Config.DeadLetterSubject)
+	}
+
+	_, err := n.JS.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       n.Config.Stream,
+		Subjects:   subjects,
+		Duplicates: n.Config.DuplicateWindow,
+	})
+	if err != nil {
+		n.Log.Error("NATS stream declaration error", logger.Field{Key: "error", Value: err.Error()})
+	}
+
+	return err
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	NATSConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "nats_connected",
+		Help: "Whether the NATS connection is currently open (1) or not (0).",
+	})
+	NATSReconnectsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "nats_reconnects_total",
+		Help: "Total number of successful NATS reconnects.",
+	})
+)
+
+type NATSMetrics struct{}
+
+func (NATSMetrics) Register(r *prometheus.Registry) {
+	r.MustRegister(
+		NATSConnected,
+		NATSReconnectsTotal,
+	)
+}
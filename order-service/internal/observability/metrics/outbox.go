@@ -12,7 +12,7 @@ var (
 			Name: "outbox_events_total",
 			Help: "Total number of outbox events processed.",
 		},
-		[]string{"status"}, // published | failed
+		[]string{"status"}, // published | failed | unroutable
 	)
 	OutboxPublishLatency = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
@@ -25,6 +25,10 @@ var (
 		Name: "outbox_retries_total",
 		Help: "Total number of outbox event publish retries.",
 	})
+	OutboxShutdownReleasedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_shutdown_released_total",
+		Help: "Total number of claimed outbox events released back to pending on shutdown.",
+	})
 	OutboxEventsWaitingRetry = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "outbox_events_waiting_retry",
 		Help: "Number of outbox events currently waiting to be retried.",
@@ -41,6 +45,181 @@ var (
 		Name: "outbox_dlq_publish_failed_total",
 		Help: "Total number of outbox events that failed to publish to the dead-letter queue.",
 	})
+	OutboxUnroutableTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_unroutable_total",
+			Help: "Total number of mandatory outbox events returned by the broker as unroutable.",
+		},
+		[]string{"event_key"},
+	)
+	OutboxClaimsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_claims_total",
+			Help: "Total number of outbox claim queries, by what triggered them.",
+		},
+		[]string{"trigger"}, // poll | notify
+	)
+	OutboxClaimedEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_claimed_events_total",
+			Help: "Total number of outbox events claimed, by what triggered the claim.",
+		},
+		[]string{"trigger"}, // poll | notify
+	)
+	OutboxListenerConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_listener_connected",
+		Help: "Whether the outbox LISTEN connection is currently established (1) or not (0).",
+	})
+	OutboxListenerReconnectsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_listener_reconnects_total",
+		Help: "Total number of outbox LISTEN connection reconnect attempts.",
+	})
+	OutboxCDCConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_cdc_connected",
+		Help: "Whether the outbox CDC replication stream is currently established (1) or not (0).",
+	})
+	OutboxCDCReconnectsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_cdc_reconnects_total",
+		Help: "Total number of outbox CDC replication stream reconnect attempts.",
+	})
+	OutboxCDCConfirmedLSN = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_cdc_confirmed_lsn",
+		Help: "Last WAL position confirmed to the replication slot after broker confirms.",
+	})
+	OutboxCDCDecodeErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_cdc_decode_errors_total",
+		Help: "Total number of outbox rows skipped by the CDC relay because they could not be decoded.",
+	})
+	OutboxRelayLeader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_relay_leader",
+		Help: "Whether this replica currently holds outbox relay leadership (1) or not (0).",
+	})
+	OutboxLeaseRenewalsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_lease_renewals_total",
+		Help: "Total number of in-progress outbox event locks renewed by their worker.",
+	})
+	OutboxLeaseLostTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_lease_lost_total",
+		Help: "Total number of outbox state updates rejected because the worker no longer held the lock.",
+	})
+	OutboxRetentionPurgedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_retention_purged_total",
+			Help: "Total number of outbox events removed by retention.",
+		},
+		[]string{"status", "action"}, // published | failed | cancelled, archived | deleted
+	)
+	OutboxRetentionErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_retention_errors_total",
+		Help: "Total number of failed outbox retention batches.",
+	})
+	OutboxRetentionRunDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "outbox_retention_run_duration_seconds",
+			Help:    "Duration of a full outbox retention run.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+		},
+	)
+	OutboxArchiveSizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_archive_size_bytes",
+		Help: "Total on-disk size of the outbox_events_archive table, including indexes.",
+	})
+	OutboxPartitions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_partitions",
+		Help: "Number of partitions currently attached to outbox_events.",
+	})
+	OutboxPartitionsCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_partitions_created_total",
+		Help: "Total number of outbox_events partitions created ahead of time.",
+	})
+	OutboxPartitionsRemovedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_partitions_removed_total",
+			Help: "Total number of expired outbox_events partitions removed.",
+		},
+		[]string{"action"}, // detached | dropped
+	)
+	OutboxPartitionErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_partition_errors_total",
+		Help: "Total number of failed outbox partition maintenance operations.",
+	})
+	OutboxAdminEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_admin_events_total",
+			Help: "Total number of outbox events changed through the admin API.",
+		},
+		[]string{"action"}, // requeue | cancel
+	)
+	OutboxRedriveTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_redrive_total",
+			Help: "Total number of DLQ messages handled by the redrive.",
+		},
+		[]string{"mode", "result"}, // reinsert | republish, redriven | duplicate | invalid | error
+	)
+	OutboxPublishErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_publish_errors_total",
+			Help: "Total number of failed outbox publishes by error class.",
+		},
+		[]string{"class"}, // permanent | transient | connection
+	)
+	OutboxWebhookDeliveriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_webhook_deliveries_total",
+			Help: "Total number of outbox webhook requests by result.",
+		},
+		[]string{"result"}, // delivered | permanent | transient | connection
+	)
+	OutboxCompressedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_compressed_total",
+			Help: "Total number of outbox messages published compressed.",
+		},
+		[]string{"encoding"}, // gzip | zstd
+	)
+	OutboxCompressionBytesSavedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_compression_bytes_saved_total",
+			Help: "Total number of bytes saved by compressing outbox messages.",
+		},
+		[]string{"encoding"},
+	)
+	OutboxCompressionRatio = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "outbox_compression_ratio",
+			Help:    "Compressed size divided by original size of compressed outbox messages.",
+			Buckets: []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.8, 1},
+		},
+		[]string{"encoding"},
+	)
+	OutboxSchemaRejectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_schema_rejected_total",
+			Help: "Total number of outbox events rejected at write time for not matching their schema.",
+		},
+		[]string{"event_key"},
+	)
+	OutboxRewrappedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_encryption_rewrapped_total",
+			Help: "Total number of outbox data keys rewrapped with the primary keyring key.",
+		},
+		[]string{"table"},
+	)
+	OutboxRewrapErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "outbox_encryption_rewrap_errors_total",
+			Help: "Total number of failed outbox rewrap runs.",
+		},
+	)
+	OutboxWebhookLatency = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "outbox_webhook_latency_seconds",
+			Help:    "Duration of outbox webhook requests.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+		},
+	)
 )
 
 type OutboxEventMetrics struct{}
@@ -51,9 +230,41 @@ func (OutboxEventMetrics) Register(r *prometheus.Registry) {
 		OutboxEventsTotal,
 		OutboxPublishLatency,
 		OutboxRetriesTotal,
+		OutboxShutdownReleasedTotal,
 		OutboxEventsWaitingRetry,
 		OutboxRetryExhaustionsTotal,
 		OutboxDLQPublishedTotal,
 		OutboxDLQPublishFailedTotal,
+		OutboxUnroutableTotal,
+		OutboxClaimsTotal,
+		OutboxClaimedEventsTotal,
+		OutboxListenerConnected,
+		OutboxListenerReconnectsTotal,
+		OutboxCDCConnected,
+		OutboxCDCReconnectsTotal,
+		OutboxCDCConfirmedLSN,
+		OutboxCDCDecodeErrorsTotal,
+		OutboxRelayLeader,
+		OutboxLeaseRenewalsTotal,
+		OutboxLeaseLostTotal,
+		OutboxRetentionPurgedTotal,
+		OutboxRetentionErrorsTotal,
+		OutboxRetentionRunDuration,
+		OutboxArchiveSizeBytes,
+		OutboxPartitions,
+		OutboxPartitionsCreatedTotal,
+		OutboxPartitionsRemovedTotal,
+		OutboxPartitionErrorsTotal,
+		OutboxAdminEventsTotal,
+		OutboxRedriveTotal,
+		OutboxPublishErrorsTotal,
+		OutboxWebhookDeliveriesTotal,
+		OutboxWebhookLatency,
+		OutboxSchemaRejectedTotal,
+		OutboxCompressedTotal,
+		OutboxCompressionBytesSavedTotal,
+		OutboxCompressionRatio,
+		OutboxRewrappedTotal,
+		OutboxRewrapErrorsTotal,
 	)
 }
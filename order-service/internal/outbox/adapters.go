@@ -0,0 +1,275 @@
+package outbox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/nats"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/rabbitmq"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/schema"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/amqppublisher"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/cloudevents"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/compress"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/encryption"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/gormstore"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/memorybroker"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/natspublisher"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/webhookpublisher"
+	"gorm.io/gorm"
+)
+
+const (
+	TransportAMQP    = "amqp"
+	TransportMemory  = "memory"
+	TransportNATS    = "nats"
+	TransportWebhook = "webhook"
+
+	transportSeparator = "+"
+)
+
+// UsesTransport reports whether the default transport or any of the
+// per event key routes is or fans out to transport.
+func UsesTransport(cfg *config.Outbox, transport string) bool {
+	for _, t := range strings.Split(cfg.Transport, transportSeparator) {
+		if t == transport {
+			return true
+		}
+	}
+	for _, route := range cfg.TransportRoutes {
+		for _, t := range strings.Split(route, transportSeparator) {
+			if t == transport {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NewTransportPublisher routes every event to the publisher of its transport,
+// as configured by Transport and TransportRoutes. A transport such as
+// "amqp+webhook" publishes to all of them, and tracker records which of them
+// confirmed an event so a retry skips those.
+func NewTransportPublisher(
+	cfg *config.Outbox,
+	publishers map[string]outboxlib.Publisher,
+	tracker outboxlib.DeliveryTracker,
+) (outboxlib.Publisher, error) {
+	defaultPublisher, err := transportPublisher(cfg.Transport, publishers, tracker)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.TransportRoutes) == 0 {
+		return defaultPublisher, nil
+	}
+
+	routes := map[string]outboxlib.Publisher{}
+	for key, transport := range cfg.TransportRoutes {
+		publisher, err := transportPublisher(transport, publishers, tracker)
+		if err != nil {
+			return nil, fmt.Errorf("event key %q: %w", key, err)
+		}
+		routes[key] = publisher
+	}
+
+	return outboxlib.NewRouter(defaultPublisher, routes), nil
+}
+
+func transportPublisher(
+	transport string,
+	publishers map[string]outboxlib.Publisher,
+	tracker outboxlib.DeliveryTracker,
+) (outboxlib.Publisher, error) {
+	var fanout []outboxlib.Destination
+	for _, t := range strings.Split(transport, transportSeparator) {
+		publisher, ok := publishers[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown outbox transport %q", t)
+		}
+		fanout = append(fanout, outboxlib.Destination{Name: t, Publisher: publisher})
+	}
+	if len(fanout) == 1 {
+		return fanout[0].Publisher, nil
+	}
+
+	return outboxlib.NewFanout(tracker, fanout...), nil
+}
+
+// NewStore configures the Postgres store of pkg/outbox for this service. Every
+// insert, also of redriven events, is validated against registry before it is
+// encrypted and written.
+func NewStore(db *gorm.DB, cfg *config.Outbox, cipher *encryption.Cipher, registry *schema.Registry) *gormstore.Store {
+	opts := []gormstore.Option{
+		gormstore.WithLockLease(cfg.LockLease),
+		gormstore.WithValidator(NewValidator(registry)),
+	}
+	if cfg.NotifyEnabled {
+		opts = append(opts, gormstore.WithNotify(cfg.NotifyChannel))
+	}
+	if cipher != nil {
+		opts = append(opts, gormstore.WithCipher(cipher))
+	}
+
+	return gormstore.New(db, opts...)
+}
+
+// NewValidator checks JSON payloads against the registered schema of their
+// event key, so a malformed event rolls back the producer's transaction
+// instead of reaching consumers. Binary payloads are validated by their
+// encoder and only need a schema reference to be decoded with.
+func NewValidator(registry *schema.Registry) func(event *outboxlib.Event) error {
+	return func(event *outboxlib.Event) error {
+		if !outboxlib.IsJSON(event.PayloadContentType()) {
+			if event.SchemaRef == "" {
+				return fmt.Errorf("%w: %s payload of %s needs a schema reference",
+					schema.ErrInvalidPayload, event.PayloadContentType(), event.EventKey)
+			}
+			return nil
+		}
+
+		if err := registry.Validate(event.EventKey, event.Payload); err != nil {
+			metrics.OutboxSchemaRejectedTotal.WithLabelValues(event.EventKey).Inc()
+			return err
+		}
+
+		return nil
+	}
+}
+
+// NewCipher loads the keyring payloads are encrypted with at rest. It
+// returns nil when no keyring is configured.
+func NewCipher(cfg *config.Encryption) (*encryption.Cipher, error) {
+	if cfg.KeyringFile == "" {
+		return nil, nil
+	}
+
+	keyring, err := encryption.LoadKeyring(cfg.KeyringFile)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string][]string, len(cfg.Fields))
+	for eventKey, list := range cfg.Fields {
+		fields[eventKey] = strings.Split(list, "|")
+	}
+
+	return encryption.New(keyring, encryption.WithFields(fields)), nil
+}
+
+// NewPublisher configures the AMQP publisher of pkg/outbox on top of the
+// shared connection, which takes care of reconnecting.
+func NewPublisher(
+	rmq rabbitmq.RabbitMQService,
+	cfg *config.AMQP,
+	ceConfig *config.CloudEvents,
+) (*amqppublisher.Publisher, error) {
+	opts := []amqppublisher.Option{
+		amqppublisher.WithExchange(cfg.Exchange),
+		amqppublisher.WithDeadLetter(cfg.DLX, cfg.DLQ),
+		amqppublisher.WithMandatory(cfg.MandatoryEventKeys...),
+		amqppublisher.WithPublishTimeout(cfg.PublishTimeout),
+	}
+
+	if ceConfig.Mode != "" {
+		mode, err := cloudevents.ParseMode(ceConfig.Mode)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, amqppublisher.WithCloudEvents(NewCloudEventsEncoder(ceConfig), mode))
+	}
+	if cfg.Compression != "" {
+		compressor, err := compress.New(cfg.Compression, cfg.CompressionThreshold,
+			compress.WithObserver(observeCompression),
+		)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, amqppublisher.WithCompression(compressor))
+	}
+
+	return amqppublisher.New(rmq.NewChannel, opts...), nil
+}
+
+func observeCompression(r *compress.Result) {
+	metrics.OutboxCompressedTotal.WithLabelValues(r.Encoding).Inc()
+	metrics.OutboxCompressionBytesSavedTotal.WithLabelValues(r.Encoding).Add(float64(r.OriginalSize - r.CompressedSize))
+	metrics.OutboxCompressionRatio.WithLabelValues(r.Encoding).Observe(float64(r.CompressedSize) / float64(r.OriginalSize))
+}
+
+// NewCloudEventsEncoder derives CloudEvents attributes from the service config.
+func NewCloudEventsEncoder(cfg *config.CloudEvents) *cloudevents.Encoder {
+	opts := []cloudevents.Option{cloudevents.WithTypePrefix(cfg.TypePrefix)}
+	if cfg.DataSchemaURL != "" {
+		base := strings.TrimSuffix(cfg.DataSchemaURL, "/")
+		opts = append(opts, cloudevents.WithDataSchema(func(event *outboxlib.Event) string {
+			return base + "/" + event.EventKey
+		}))
+	}
+
+	return cloudevents.NewEncoder(cfg.Source, opts...)
+}
+
+// NewNATSPublisher configures the JetStream publisher of pkg/outbox.
+func NewNATSPublisher(n nats.NATSService, cfg *config.NATS) *natspublisher.Publisher {
+	return natspublisher.New(n.JetStream(),
+		natspublisher.WithSubjectPrefix(cfg.SubjectPrefix),
+		natspublisher.WithDeadLetter(cfg.DeadLetterSubject),
+		natspublisher.WithPublishTimeout(cfg.PublishTimeout),
+	)
+}
+
+// NewMemoryBroker returns an in-process broker for running without RabbitMQ.
+// Every message is logged until the broker is closed, which also keeps it
+// from blocking on a full subscription.
+func NewMemoryBroker(log logger.Logger, cfg *config.AMQP) *memorybroker.Broker {
+	broker := memorybroker.New(
+		memorybroker.WithDeadLetter(cfg.DLQ),
+		memorybroker.WithMandatory(cfg.MandatoryEventKeys...),
+	)
+
+	sub := broker.Subscribe("#", 100)
+	go func() {
+		for msg := range sub.Messages() {
+			log.Info("In-memory broker delivered message",
+				logger.Field{Key: "message_id", Value: msg.ID},
+				logger.Field{Key: "routing_key", Value: msg.RoutingKey},
+				logger.Field{Key: "body", Value: string(msg.Body)},
+			)
+		}
+	}()
+
+	return broker
+}
+
+// NewWebhookPublisher delivers events to the webhook subscriptions. Endpoints
+// have no dead-letter queue of their own, so dead letters go to deadLetter,
+// without one they fail and the event is marked failed.
+func NewWebhookPublisher(
+	subscriptions webhookpublisher.Subscriptions,
+	cfg *config.Webhook,
+	deadLetter outboxlib.Publisher,
+) *webhookpublisher.Publisher {
+	opts := []webhookpublisher.Option{
+		webhookpublisher.WithTimeout(cfg.DefaultTimeout),
+		webhookpublisher.WithObserver(observeWebhookDelivery),
+	}
+	if deadLetter != nil {
+		opts = append(opts, webhookpublisher.WithDeadLetter(deadLetter))
+	}
+
+	return webhookpublisher.New(subscriptions, opts...)
+}
+
+func observeWebhookDelivery(d *webhookpublisher.Delivery) {
+	result := "delivered"
+	if d.Err != nil {
+		result = string(outboxlib.Classify(d.Err))
+	}
+
+	metrics.OutboxWebhookDeliveriesTotal.WithLabelValues(result).Inc()
+	metrics.OutboxWebhookLatency.Observe(d.Duration.Seconds())
+}
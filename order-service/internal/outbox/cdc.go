@@ -0,0 +1,442 @@
+package outbox
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+const (
+	RelayModePolling = "polling"
+	RelayModeCDC     = "cdc"
+
+	cdcStandbyStatusInterval = 10 * time.Second
+	cdcOutboxTable           = "outbox_events"
+	cdcTimestampLayout       = "2006-01-02 15:04:05.999999"
+)
+
+var errRelayStopping = errors.New("outbox relay stopping")
+
+type cdcStream struct {
+	conn       *pgconn.PgConn
+	relations  map[uint32]*pgoutputRelation
+	session    *outboxlib.SessionHolder
+	pending    []*outboxlib.Event // inserts of the transaction being decoded
+	inTxn      bool
+	confirmed  lsn
+	nextStatus time.Time
+}
+
+// StartCDC relays outbox inserts read from a logical replication slot in commit
+// order. The slot's confirmed LSN only moves past a transaction once all of its
+// events were confirmed by the broker, so a crash replays events, never skips them.
+// Status columns of outbox_events are not touched in this mode.
+func (o *Outbox) StartCDC(ctx context.Context) {
+	o.log.Info("Outbox CDC relay started",
+		logger.Field{Key: "slot", Value: o.config.ReplicationSlot},
+		logger.Field{Key: "publication", Value: o.config.Publication},
+	)
+
+	// A single session publishing one event at a time keeps them in commit order.
+	delay := reconnectMinBackoff
+
+	for {
+		streamed, err := o.streamChanges(ctx)
+		metrics.OutboxCDCConnected.Set(0)
+		if ctx.Err() != nil || o.isStopping() {
+			return
+		}
+		if streamed {
+			delay = reconnectMinBackoff
+		}
+
+		metrics.OutboxCDCReconnectsTotal.Inc()
+		o.log.Warn("Outbox CDC stream stopped, reconnecting",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "backoff_seconds", Value: delay.Seconds()},
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.stopping:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, reconnectMaxBackoff)
+	}
+}
+
+func (o *Outbox) streamChanges(ctx context.Context) (bool, error) {
+	cfg, err := pgconn.ParseConfig(o.databaseConfig.DSN)
+	if err != nil {
+		return false, err
+	}
+	cfg.RuntimeParams["replication"] = "database"
+
+	conn, err := pgconn.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if err := o.ensureReplicationSlot(ctx, conn); err != nil {
+		return false, err
+	}
+	if err := o.startReplication(ctx, conn); err != nil {
+		return false, err
+	}
+
+	metrics.OutboxCDCConnected.Set(1)
+	o.log.Info("Outbox CDC stream connected", logger.Field{Key: "slot", Value: o.config.ReplicationSlot})
+
+	s := &cdcStream{
+		conn:       conn,
+		session:    outboxlib.NewSessionHolder(o.publisher),
+		relations:  map[uint32]*pgoutputRelation{},
+		nextStatus: time.Now().Add(cdcStandbyStatusInterval),
+	}
+	defer s.session.Close()
+
+	// Stop ends receiving, a transaction that was already received is still relayed.
+	receiveCtx, cancelReceive := o.untilStopping(ctx)
+	defer cancelReceive()
+
+	for {
+		if !time.Now().Before(s.nextStatus) {
+			if err := s.sendStatus(); err != nil {
+				return true, err
+			}
+		}
+
+		recvCtx, cancel := context.WithDeadline(receiveCtx, s.nextStatus)
+		msg, err := conn.ReceiveMessage(recvCtx)
+		cancel()
+		if err != nil {
+			if pgconn.Timeout(err) && receiveCtx.Err() == nil {
+				continue
+			}
+			return true, err
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.ErrorResponse:
+			return true, pgconn.ErrorResponseToPgError(msg)
+		case *pgproto3.CopyData:
+			if err := o.handleCopyData(ctx, s, msg.Data); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+func (o *Outbox) ensureReplicationSlot(ctx context.Context, conn *pgconn.PgConn) error {
+	query := fmt.Sprintf(
+		"CREATE_REPLICATION_SLOT %s LOGICAL pgoutput NOEXPORT_SNAPSHOT",
+		pgx.Identifier{o.config.ReplicationSlot}.Sanitize(),
+	)
+
+	_, err := conn.Exec(ctx, query).ReadAll()
+
+	// duplicate_object: the slot survived a previous run, resume from it
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42710" {
+		return nil
+	}
+	if err == nil {
+		o.log.Info("Outbox replication slot created", logger.Field{Key: "slot", Value: o.config.ReplicationSlot})
+	}
+
+	return err
+}
+
+func (o *Outbox) startReplication(ctx context.Context, conn *pgconn.PgConn) error {
+	// 0/0 resumes from the slot's confirmed_flush_lsn
+	query := fmt.Sprintf(
+		"START_REPLICATION SLOT %s LOGICAL 0/0 (proto_version '1', publication_names '%s')",
+		pgx.Identifier{o.config.ReplicationSlot}.Sanitize(),
+		strings.ReplaceAll(o.config.Publication, "'", "''"),
+	)
+
+	conn.Frontend().Send(&pgproto3.Query{String: query})
+	if err := conn.Frontend().Flush(); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			return nil
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(msg)
+		}
+	}
+}
+
+func (o *Outbox) handleCopyData(ctx context.Context, s *cdcStream, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	switch data[0] {
+	case primaryKeepaliveByteID:
+		keepalive, err := parsePrimaryKeepalive(data[1:])
+		if err != nil {
+			return err
+		}
+		// Nothing is buffered between transactions, so everything sent so far is done.
+		if !s.inTxn && keepalive.walEnd > s.confirmed {
+			s.confirm(keepalive.walEnd)
+		}
+		if keepalive.replyRequested {
+			return s.sendStatus()
+		}
+
+	case xLogDataByteID:
+		xld, err := parseXLogData(data[1:])
+		if err != nil {
+			return err
+		}
+		msg, err := decodePgoutput(xld.data)
+		if err != nil {
+			return err
+		}
+		return o.handleReplicationMessage(ctx, s, msg)
+	}
+
+	return nil
+}
+
+func (o *Outbox) handleReplicationMessage(ctx context.Context, s *cdcStream, msg any) error {
+	switch msg := msg.(type) {
+	case *pgoutputRelation:
+		s.relations[msg.id] = msg
+
+	case *pgoutputBegin:
+		s.inTxn = true
+		s.pending = s.pending[:0]
+
+	case *pgoutputInsert:
+		relation, ok := s.relations[msg.relationID]
+		if !ok || relation.name != cdcOutboxTable {
+			return nil
+		}
+
+		// A row that cannot be decoded never will be, replaying it would stall
+		// the stream on the same LSN. It is skipped and reported instead.
+		event, err := outboxEventFromTuple(relation.columns, msg.values)
+		if err != nil {
+			metrics.OutboxCDCDecodeErrorsTotal.Inc()
+			o.log.WithContext(ctx).Error("Skipping outbox row that could not be decoded from WAL",
+				logger.Field{Key: "error", Value: err.Error()},
+				logger.Field{Key: "event_id", Value: tupleValue(relation.columns, msg.values, "id")},
+			)
+			return nil
+		}
+		s.pending = append(s.pending, event)
+
+	case *pgoutputCommit:
+		for _, event := range s.pending {
+			if err := o.relayEvent(ctx, s, event); err != nil {
+				return err
+			}
+		}
+
+		s.pending = s.pending[:0]
+		s.inTxn = false
+		s.confirm(msg.endLSN)
+	}
+
+	return nil
+}
+
+// relayEvent retries in place so later events never overtake this one. Once
+// retries are exhausted the event goes to the DLQ and the stream moves on.
+func (o *Outbox) relayEvent(ctx context.Context, s *cdcStream, event *outboxlib.Event) error {
+	outcome := outboxlib.OutcomePublished
+	ctx, finish := o.startEventSpan(ctx, event)
+	defer func() { finish(outcome) }()
+
+	// A payload that cannot be decrypted is dead-lettered like any other
+	// permanent failure.
+	event, decryptErr := o.decrypt(ctx, event)
+
+	for {
+		err := decryptErr
+		if err == nil {
+			err = s.session.Publish(ctx, event)
+		}
+		if err == nil {
+			o.observePublished(ctx, event)
+			return nil
+		}
+
+		class := outboxlib.Classify(err)
+		o.observePublishFailed(ctx, event, class, err)
+		o.log.WithContext(ctx).Error("Failed to publish outbox event",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "event_id", Value: event.ID},
+			logger.Field{Key: "class", Value: string(class)},
+		)
+
+		policy := o.retryPolicies.ForError(event.EventKey, err)
+		exhausted := event.RetryCount >= policy.MaxAttempts()
+		if exhausted {
+			o.observeRetriesExhausted(ctx, event)
+		}
+
+		if class == outboxlib.ErrorClassPermanent || (exhausted && class == outboxlib.ErrorClassTransient) {
+			dlqErr := s.session.DeadLetter(ctx, event, err)
+			o.observeDeadLettered(ctx, event, dlqErr)
+			if dlqErr != nil {
+				outcome = outboxlib.OutcomeFailed
+				return dlqErr
+			}
+			outcome = outboxlib.OutcomeDLQ
+			if class == outboxlib.ErrorClassPermanent {
+				outcome = outboxlib.OutcomePermanent
+			}
+			return nil
+		}
+
+		// Connection errors are not the event's fault, so the retry budget is left alone.
+		delay := reconnectMinBackoff
+		outcome = outboxlib.OutcomeConnection
+		if class != outboxlib.ErrorClassConnection {
+			event.RetryCount++
+			delay = policy.Delay(event.RetryCount)
+			outcome = outboxlib.OutcomeRetry
+			o.observeRetry(ctx, event, delay)
+		}
+
+		// Keep the walsender from timing out while backing off.
+		if err := s.sendStatus(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-o.stopping:
+			// The transaction is not confirmed yet, so it is replayed after a restart.
+			return errRelayStopping
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *cdcStream) confirm(pos lsn) {
+	s.confirmed = pos
+	metrics.OutboxCDCConfirmedLSN.Set(float64(pos))
+}
+
+func (s *cdcStream) sendStatus() error {
+	s.conn.Frontend().Send(&pgproto3.CopyData{Data: encodeStandbyStatusUpdate(s.confirmed, time.Now())})
+	if err := s.conn.Frontend().Flush(); err != nil {
+		return err
+	}
+
+	s.nextStatus = time.Now().Add(cdcStandbyStatusInterval)
+	return nil
+}
+
+func outboxEventFromTuple(columns []string, values []*string) (*outboxlib.Event, error) {
+	event := &outboxlib.Event{}
+	encryption := &outboxlib.Encryption{}
+
+	for i, column := range columns {
+		if i >= len(values) || values[i] == nil {
+			continue
+		}
+		value := *values[i]
+
+		switch column {
+		case "id":
+			event.ID = value
+		case "event_key":
+			event.EventKey = value
+		case "payload":
+			payload, err := decodeBytea(value)
+			if err != nil {
+				return nil, err
+			}
+			event.Payload = payload
+		case "content_type":
+			event.ContentType = value
+		case "schema_ref":
+			event.SchemaRef = value
+		case "key_id":
+			encryption.KeyID = value
+		case "wrapped_key":
+			wrappedKey, err := decodeBytea(value)
+			if err != nil {
+				return nil, err
+			}
+			encryption.WrappedKey = wrappedKey
+		case "encryption_scope":
+			encryption.Scope = value
+		case "aggregate_id":
+			event.AggregateID = value
+		case "sequence":
+			sequence, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			event.Sequence = sequence
+		case "traceparent":
+			event.Traceparent = value
+		case "created_at":
+			createdAt, err := time.Parse(cdcTimestampLayout, value)
+			if err != nil {
+				return nil, err
+			}
+			event.CreatedAt = createdAt
+		}
+	}
+
+	if event.ID == "" || event.EventKey == "" {
+		return nil, errors.New("outbox row is missing id or event_key")
+	}
+	if encryption.KeyID != "" {
+		event.Encryption = encryption
+	}
+
+	return event, nil
+}
+
+// tupleValue returns column's text value, or "" when it is missing or NULL.
+func tupleValue(columns []string, values []*string, column string) string {
+	for i, c := range columns {
+		if c == column && i < len(values) && values[i] != nil {
+			return *values[i]
+		}
+	}
+
+	return ""
+}
+
+// decodeBytea parses the hex output format pgoutput sends BYTEA columns in.
+func decodeBytea(value string) ([]byte, error) {
+	hexValue, ok := strings.CutPrefix(value, `\x`)
+	if !ok {
+		return nil, errors.New("bytea column is not in hex format")
+	}
+
+	return hex.DecodeString(hexValue)
+}
@@ -0,0 +1,105 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+// validateEncryptionConfig rejects rewrap settings that would make the
+// rewrap ticker panic or its batch loop spin forever.
+func validateEncryptionConfig(cfg *config.Encryption) error {
+	switch {
+	case cfg == nil:
+		return errors.New("outbox encryption config is missing")
+	case cfg.RewrapInterval <= 0:
+		return fmt.Errorf("OUTBOX_ENCRYPTION_REWRAP_INTERVAL must be positive, got %s", cfg.RewrapInterval)
+	case cfg.RewrapBatchSize < 1:
+		return fmt.Errorf("OUTBOX_ENCRYPTION_REWRAP_BATCH_SIZE must be at least 1, got %d", cfg.RewrapBatchSize)
+	}
+
+	return nil
+}
+
+// decrypt returns a copy of event with its plaintext payload, for the CDC
+// relay. The polling relay decrypts through outboxlib.WithCipher.
+func (o *Outbox) decrypt(ctx context.Context, event *outboxlib.Event) (*outboxlib.Event, error) {
+	var cipher outboxlib.Cipher
+	if o.cipher != nil {
+		cipher = o.cipher
+	}
+
+	plain, err := outboxlib.DecryptEvent(cipher, event)
+	if err != nil {
+		o.log.WithContext(ctx).Error("Failed to decrypt outbox event",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "event_id", Value: event.ID},
+			logger.Field{Key: "key_id", Value: event.Encryption.KeyID},
+		)
+	}
+	return plain, err
+}
+
+func (o *Outbox) startRewrap(ctx context.Context) {
+	ticker := time.NewTicker(o.encryptionConfig.RewrapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.runRewrap(ctx)
+		}
+	}
+}
+
+// runRewrap rewraps the data keys of live events that are not wrapped with
+// the primary key yet, so rotated keys stop being needed by the relay. The
+// archive is only rewrapped on request with outboxctl, archived payloads stay
+// with their key so destroying it still shreds them.
+//
+// The keyring is reloaded before every batch, so keys outboxctl marks as being
+// destroyed are left alone from the next batch on and can be shredded.
+func (o *Outbox) runRewrap(ctx context.Context) {
+	keyring := o.cipher.Keyring()
+
+	var total int64
+	for ctx.Err() == nil {
+		if err := keyring.Reload(); err != nil {
+			metrics.OutboxRewrapErrorsTotal.Inc()
+			o.log.Error("Failed to reload outbox keyring, keeping the loaded keys",
+				logger.Field{Key: "error", Value: err.Error()},
+			)
+		}
+
+		// Batches are bounded like retention, so rotating a large table never
+		// holds locks for long.
+		count, err := o.outboxEventService.RewrapKeys(ctx, false, keyring.Stale(), o.encryptionConfig.RewrapBatchSize, o.cipher.Rewrap)
+		if err != nil {
+			metrics.OutboxRewrapErrorsTotal.Inc()
+			o.log.Error("Failed to rewrap outbox data keys", logger.Field{Key: "error", Value: err.Error()})
+			break
+		}
+
+		total += count
+		metrics.OutboxRewrappedTotal.WithLabelValues("outbox_events").Add(float64(count))
+
+		if count < int64(o.encryptionConfig.RewrapBatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		o.log.Info("Outbox data keys rewrapped",
+			logger.Field{Key: "primary_key_id", Value: keyring.Primary()},
+			logger.Field{Key: "count", Value: total},
+		)
+	}
+}
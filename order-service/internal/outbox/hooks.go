@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/tracing"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// relayHooks records the relay's metrics and traces. The CDC relay reports
+// through the same methods.
+func (o *Outbox) relayHooks() outboxlib.Hooks {
+	return outboxlib.Hooks{
+		Claimed:          o.observeClaim,
+		StartEvent:       o.startEventSpan,
+		Published:        o.observePublished,
+		PublishFailed:    o.observePublishFailed,
+		RetryScheduled:   o.observeRetry,
+		RetriesExhausted: o.observeRetriesExhausted,
+		DeadLettered:     o.observeDeadLettered,
+		LeasesRenewed: func(count int64) {
+			metrics.OutboxLeaseRenewalsTotal.Add(float64(count))
+		},
+		LeaseLost: func(context.Context, *outboxlib.Event) {
+			metrics.OutboxLeaseLostTotal.Inc()
+		},
+		Released: func(count int) {
+			metrics.OutboxShutdownReleasedTotal.Add(float64(count))
+		},
+		Backlog: func(counts *outboxlib.Counts) {
+			metrics.OutboxBacklog.Set(float64(counts.Backlog))
+			metrics.OutboxEventsWaitingRetry.Set(float64(counts.WaitingRetry))
+		},
+	}
+}
+
+func (o *Outbox) observeClaim(trigger string, count int) {
+	metrics.OutboxClaimsTotal.WithLabelValues(trigger).Inc()
+	metrics.OutboxClaimedEventsTotal.WithLabelValues(trigger).Add(float64(count))
+}
+
+func (o *Outbox) startEventSpan(ctx context.Context, event *outboxlib.Event) (context.Context, func(outboxlib.Outcome)) {
+	if event.Traceparent != "" {
+		ctx = tracing.ExtractTraceParent(ctx, event.Traceparent)
+	}
+
+	ctx, span := tracing.Tracer.Start(
+		ctx,
+		"Outbox.PublishEvent",
+		trace.WithAttributes(
+			attribute.String("event.id", event.ID),
+			attribute.String("event.key", event.EventKey),
+			attribute.Int("event.retry_count", event.RetryCount),
+			attribute.String("outbox.relay_mode", o.config.RelayMode),
+		),
+	)
+
+	return ctx, func(outcome outboxlib.Outcome) {
+		span.SetAttributes(attribute.String("outbox.outcome", string(outcome)))
+		span.End()
+	}
+}
+
+func (o *Outbox) observePublished(_ context.Context, event *outboxlib.Event) {
+	metrics.OutboxPublishLatency.Observe(time.Since(event.CreatedAt).Seconds())
+	metrics.OutboxEventsTotal.WithLabelValues("published").Inc()
+}
+
+func (o *Outbox) observePublishFailed(_ context.Context, event *outboxlib.Event, class outboxlib.ErrorClass, err error) {
+	metrics.OutboxPublishErrorsTotal.WithLabelValues(string(class)).Inc()
+
+	if errors.Is(err, outboxlib.ErrUnroutable) {
+		metrics.OutboxEventsTotal.WithLabelValues("unroutable").Inc()
+		metrics.OutboxUnroutableTotal.WithLabelValues(event.EventKey).Inc()
+		return
+	}
+	metrics.OutboxEventsTotal.WithLabelValues("failed").Inc()
+}
+
+func (o *Outbox) observeRetry(context.Context, *outboxlib.Event, time.Duration) {
+	metrics.OutboxRetriesTotal.Inc()
+}
+
+func (o *Outbox) observeRetriesExhausted(context.Context, *outboxlib.Event) {
+	metrics.OutboxRetryExhaustionsTotal.Inc()
+}
+
+func (o *Outbox) observeDeadLettered(_ context.Context, _ *outboxlib.Event, err error) {
+	if err != nil {
+		metrics.OutboxDLQPublishFailedTotal.Inc()
+		return
+	}
+	metrics.OutboxDLQPublishedTotal.Inc()
+}
@@ -0,0 +1,159 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+)
+
+const (
+	RoleActive  = "active" // leader election disabled, every replica relays
+	RoleLeader  = "leader"
+	RoleStandby = "standby"
+)
+
+// Role reports whether this replica is currently relaying events.
+func (o *Outbox) Role() string {
+	if !o.config.LeaderElectionEnabled {
+		return RoleActive
+	}
+	if o.leader.Load() {
+		return RoleLeader
+	}
+	return RoleStandby
+}
+
+// runWithLeaderElection only runs the relay while this replica holds the
+// session-level advisory lock. Standbys retry every LeaderCheckInterval.
+func (o *Outbox) runWithLeaderElection(ctx context.Context, run func(ctx context.Context)) {
+	metrics.OutboxRelayLeader.Set(0)
+
+	for {
+		err := o.campaign(ctx, run)
+		if ctx.Err() != nil || o.isStopping() {
+			return
+		}
+		if err != nil {
+			o.log.Warn("Outbox leader election interrupted",
+				logger.Field{Key: "error", Value: err.Error()},
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.stopping:
+			return
+		case <-time.After(o.config.LeaderCheckInterval):
+		}
+	}
+}
+
+func (o *Outbox) campaign(ctx context.Context, run func(ctx context.Context)) error {
+	conn, err := o.connectLeaderLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	ticker := time.NewTicker(o.config.LeaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		var acquired bool
+		err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", o.config.LeaderLockKey).Scan(&acquired)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return o.lead(ctx, conn, run)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-o.stopping:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead runs the relay until ctx is cancelled or the lock connection can no
+// longer be verified, in which case another replica may already own the lock.
+func (o *Outbox) lead(ctx context.Context, conn *pgx.Conn, run func(ctx context.Context)) error {
+	o.setLeader(true)
+	defer o.setLeader(false)
+
+	o.log.Info("Outbox relay acquired leadership", logger.Field{Key: "lock_key", Value: o.config.LeaderLockKey})
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(o.config.LeaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			<-done
+			return nil
+
+		case <-done:
+			if o.isStopping() {
+				return nil
+			}
+			return errors.New("outbox relay stopped while holding leadership")
+
+		case <-ticker.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, o.config.LeaderCheckInterval)
+			err := conn.Ping(pingCtx)
+			cancelPing()
+			if err != nil {
+				cancel()
+				<-done
+				o.log.Warn("Outbox relay lost leadership")
+				return fmt.Errorf("leader lock connection lost: %w", err)
+			}
+		}
+	}
+}
+
+// connectLeaderLock opens the dedicated lock connection with aggressive TCP
+// keepalives so Postgres releases the lock of a dead leader within a few
+// check intervals instead of the OS default of hours.
+func (o *Outbox) connectLeaderLock(ctx context.Context) (*pgx.Conn, error) {
+	cfg, err := pgx.ParseConfig(o.databaseConfig.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := strconv.Itoa(max(int(o.config.LeaderCheckInterval.Seconds()), 1))
+	cfg.RuntimeParams["tcp_keepalives_idle"] = seconds
+	cfg.RuntimeParams["tcp_keepalives_interval"] = seconds
+	cfg.RuntimeParams["tcp_keepalives_count"] = "2"
+	cfg.RuntimeParams["application_name"] = "outbox-leader-lock"
+
+	return pgx.ConnectConfig(ctx, cfg)
+}
+
+func (o *Outbox) setLeader(leader bool) {
+	o.leader.Store(leader)
+	if leader {
+		metrics.OutboxRelayLeader.Set(1)
+	} else {
+		metrics.OutboxRelayLeader.Set(0)
+	}
+}
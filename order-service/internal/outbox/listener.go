@@ -0,0 +1,67 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+)
+
+// listenForNotifications keeps a dedicated LISTEN connection open and wakes
+// the relay on every notification, reconnecting with capped backoff.
+func (o *Outbox) listenForNotifications(ctx context.Context) {
+	delay := reconnectMinBackoff
+
+	for {
+		connected, err := o.listen(ctx)
+		if ctx.Err() != nil {
+			metrics.OutboxListenerConnected.Set(0)
+			return
+		}
+		if connected {
+			delay = reconnectMinBackoff
+		}
+
+		metrics.OutboxListenerConnected.Set(0)
+		metrics.OutboxListenerReconnectsTotal.Inc()
+		o.log.Warn("Outbox listener disconnected, reconnecting",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "backoff_seconds", Value: delay.Seconds()},
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, reconnectMaxBackoff)
+	}
+}
+
+func (o *Outbox) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.Connect(ctx, o.databaseConfig.DSN)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{o.config.NotifyChannel}.Sanitize())
+	if err != nil {
+		return false, err
+	}
+
+	metrics.OutboxListenerConnected.Set(1)
+	o.log.Info("Outbox listener connected", logger.Field{Key: "channel", Value: o.config.NotifyChannel})
+
+	// Catch up on events inserted while the listener was down.
+	o.relay.Wake()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return true, err
+		}
+		o.relay.Wake()
+	}
+}
@@ -4,109 +4,207 @@ import (
 	"context"
 	"os"
 	"sync"
-	"time"
+	"sync/atomic"
 
-	"github.com/rabbitmq/amqp091-go"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
-	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database"
-	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
-	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/rabbitmq"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
-	"gorm.io/gorm"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/encryption"
 )
 
 type OutboxService interface {
-	Start(ctx context.Context, workerID string)
+	Role() string
+	Stop(ctx context.Context) error
 }
 
+// Outbox runs the relay of pkg/outbox in polling mode, or the CDC relay, plus
+// the housekeeping around it: leader election, retention and partitions.
 type Outbox struct {
-	db                 *gorm.DB
 	log                logger.Logger
 	outboxEventService service.OutboxEventService
-	rabbitmq           rabbitmq.RabbitMQService
-	channels           []*amqp091.Channel
+	store              outboxlib.Store
+	publisher          outboxlib.Publisher
+	relay              *outboxlib.Relay   // nil in CDC mode
+	cipher             *encryption.Cipher // nil when payloads are stored in plaintext
 	config             *config.Outbox
-	amqpConfig         *config.AMQP
+	databaseConfig     *config.Database
+	encryptionConfig   *config.Encryption
+	leader             atomic.Bool
+	retryPolicies      *outboxlib.RetryPolicies
+	cancel             context.CancelFunc // aborts whatever is still running, see Stop
+	stopping           chan struct{}      // closed by Stop, no new events are claimed
+	stopOnce           sync.Once
+	done               chan struct{} // closed once the relay returned
 }
 
 type Opts struct {
-	DB                 database.DatabaseService
 	Log                logger.Logger
 	OutboxEventService service.OutboxEventService
-	RabbitMQ           rabbitmq.RabbitMQService
+	Store              outboxlib.Store
+	Publisher          outboxlib.Publisher
+	Cipher             *encryption.Cipher
+	RetryPolicies      *outboxlib.RetryPolicies // see NewRetryPolicies
 	Config             *config.Outbox
-	AMQPConfig         *config.AMQP
+	DatabaseConfig     *config.Database
+	EncryptionConfig   *config.Encryption
 }
 
-func NewOutbox(ctx context.Context, opts *Opts) Outbox {
-	o := Outbox{
-		db:                 opts.DB.DB(),
+// NewOutbox starts the relay. It keeps running after ctx is cancelled, only
+// Stop ends it, so in-flight events can be drained before shutdown.
+func NewOutbox(ctx context.Context, opts *Opts) (*Outbox, error) {
+	if opts.Cipher != nil {
+		if err := validateEncryptionConfig(opts.EncryptionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	o := &Outbox{
 		log:                opts.Log,
 		outboxEventService: opts.OutboxEventService,
-		rabbitmq:           opts.RabbitMQ,
+		store:              opts.Store,
+		publisher:          opts.Publisher,
+		cipher:             opts.Cipher,
 		config:             opts.Config,
-		amqpConfig:         opts.AMQPConfig,
+		databaseConfig:     opts.DatabaseConfig,
+		encryptionConfig:   opts.EncryptionConfig,
+		retryPolicies:      opts.RetryPolicies,
+		cancel:             cancel,
+		stopping:           make(chan struct{}),
+		done:               make(chan struct{}),
 	}
 
-	hostname, _ := os.Hostname()
-	go o.Start(ctx, hostname)
-	go o.startMetricsReporter(ctx)
+	var run func(ctx context.Context)
+	switch o.config.RelayMode {
+	case RelayModeCDC:
+		// Backlog gauges are derived from status columns, which CDC mode never updates.
+		run = o.StartCDC
+	default:
+		relay, err := o.newRelay()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		o.relay = relay
+		run = o.runRelay
+	}
+
+	// Expired partitions are dropped as a whole, so row-level retention is not needed.
+	if o.config.RetentionEnabled && !o.config.PartitioningEnabled {
+		go o.startRetention(ctx)
+	}
 
-	return o
+	go func() {
+		defer close(o.done)
+		if o.config.LeaderElectionEnabled {
+			o.runWithLeaderElection(ctx, o.withMaintenance(run))
+		} else {
+			o.withMaintenance(run)(ctx)
+		}
+	}()
+
+	return o, nil
 }
 
-func (o *Outbox) Start(ctx context.Context, workerID string) {
-	o.log.Info("Outbox worker started")
+// withMaintenance runs the partition manager and the key rewrap next to the
+// relay, so with leader election only the replica holding the lock runs them.
+func (o *Outbox) withMaintenance(run func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		if o.config.PartitioningEnabled {
+			go o.startPartitionManager(ctx)
+		}
+		if o.cipher != nil {
+			go o.startRewrap(ctx)
+		}
 
-	if err := o.initChannels(o.config.MaxConcurrency); err != nil {
-		o.log.Error("Failed to initialize channels", logger.Field{Key: "error", Value: err.Error()})
-		return
+		run(ctx)
 	}
-	defer o.closeChannels()
+}
 
-	eventsCh := make(chan *model.OutboxEvent, o.config.MaxConcurrency)
-	wg := sync.WaitGroup{}
+func (o *Outbox) newRelay() (*outboxlib.Relay, error) {
+	hostname, _ := os.Hostname()
 
-	for i := 0; i < o.config.MaxConcurrency; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			o.processEvents(ctx, workerID, o.channels[i], eventsCh)
-		}(i)
+	opts := []outboxlib.Option{
+		outboxlib.WithOwner(hostname),
+		outboxlib.WithInterval(o.config.Interval),
+		outboxlib.WithBatchSize(o.config.BatchSize),
+		outboxlib.WithConcurrency(o.config.MaxConcurrency),
+		outboxlib.WithLeaseRenewInterval(o.config.LockRenewInterval),
+		outboxlib.WithBacklogInterval(o.config.BacklogReportInterval),
+		outboxlib.WithRetryPolicies(o.retryPolicies),
+		outboxlib.WithHooks(o.relayHooks()),
+		outboxlib.WithLogger(logger.NewSlogLogger(o.log)),
+	}
+	if o.cipher != nil {
+		opts = append(opts, outboxlib.WithCipher(o.cipher))
 	}
 
-	ticker := time.NewTicker(o.config.Interval)
-	defer ticker.Stop()
+	return outboxlib.New(o.store, o.publisher, opts...)
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			close(eventsCh)
-			wg.Wait()
-			return
+func (o *Outbox) runRelay(ctx context.Context) {
+	if o.config.NotifyEnabled {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go o.listenForNotifications(ctx)
+	}
 
-		case <-ticker.C:
-			o.dispatchPendingEvents(ctx, workerID, eventsCh)
-		}
+	if err := o.relay.Run(ctx); err != nil && ctx.Err() == nil {
+		o.log.Error("Outbox relay stopped", logger.Field{Key: "error", Value: err.Error()})
 	}
 }
 
-func (o *Outbox) initChannels(count int) error {
-	for i := 0; i < count; i++ {
-		ch, err := o.rabbitmq.NewChannel()
-		if err != nil {
-			return err
-		}
-		o.channels = append(o.channels, ch)
+// Stop stops claiming new events, waits for in-flight events to finish and
+// hands claimed but unstarted events back to pending. Publishes still running
+// when ctx is done are aborted; their events are reclaimed once the lease expires.
+func (o *Outbox) Stop(ctx context.Context) error {
+	o.stopOnce.Do(func() { close(o.stopping) })
+	defer o.cancel()
+
+	o.log.Info("Outbox relay stopping, draining in-flight events")
+
+	if o.relay != nil {
+		// The relay releases its claimed but unstarted events itself.
+		_ = o.relay.Stop(ctx)
+	}
+
+	select {
+	case <-o.done:
+		o.log.Info("Outbox relay stopped")
+		return nil
+	case <-ctx.Done():
+		o.cancel()
+		<-o.done
+		o.log.Warn("Outbox relay stopped before in-flight events finished")
+		return ctx.Err()
 	}
-	return nil
 }
 
-func (o *Outbox) closeChannels() {
-	for _, ch := range o.channels {
-		if ch != nil {
-			_ = ch.Close()
-		}
+func (o *Outbox) isStopping() bool {
+	select {
+	case <-o.stopping:
+		return true
+	default:
+		return false
 	}
 }
+
+// untilStopping returns a context that is also cancelled once Stop is called.
+func (o *Outbox) untilStopping(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		select {
+		case <-o.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
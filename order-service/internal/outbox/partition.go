@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+)
+
+const (
+	partitionPrefix     = "outbox_events_p"
+	partitionNameLayout = "20060102"
+	partitionSpan       = 24 * time.Hour
+)
+
+// startPartitionManager keeps daily partitions of outbox_events created ahead
+// of time and removes the ones past PartitionRetention. Rows landing outside
+// every daily range go to outbox_events_default and are never removed here.
+func (o *Outbox) startPartitionManager(ctx context.Context) {
+	o.managePartitions(ctx)
+
+	ticker := time.NewTicker(o.config.PartitionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.managePartitions(ctx)
+		}
+	}
+}
+
+func (o *Outbox) managePartitions(ctx context.Context) {
+	partitions, err := o.outboxEventService.ListPartitions(ctx)
+	if err != nil {
+		metrics.OutboxPartitionErrorsTotal.Inc()
+		o.log.Error("Failed to list outbox partitions", logger.Field{Key: "error", Value: err.Error()})
+		return
+	}
+
+	// created_at is a TIMESTAMP filled from the database clock, so day
+	// boundaries and cutoffs are computed on that clock too.
+	now, err := o.outboxEventService.DatabaseTime(ctx)
+	if err != nil {
+		metrics.OutboxPartitionErrorsTotal.Inc()
+		o.log.Error("Failed to read the database clock", logger.Field{Key: "error", Value: err.Error()})
+		return
+	}
+	now = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), time.UTC)
+
+	attached := len(partitions)
+	today := now.Truncate(partitionSpan)
+
+	for i := 0; i <= o.config.PartitionPremake; i++ {
+		from := today.Add(time.Duration(i) * partitionSpan)
+		if slices.Contains(partitions, partitionName(from)) {
+			continue
+		}
+		if o.createPartition(ctx, from) {
+			attached++
+		}
+	}
+
+	cutoff := now.Add(-o.config.PartitionRetention)
+
+	for _, partition := range partitions {
+		from, ok := parsePartitionName(partition)
+		if !ok || from.Add(partitionSpan).After(cutoff) {
+			continue
+		}
+		if o.removePartition(ctx, partition) {
+			attached--
+		}
+	}
+
+	metrics.OutboxPartitions.Set(float64(attached))
+}
+
+func (o *Outbox) createPartition(ctx context.Context, from time.Time) bool {
+	name := partitionName(from)
+
+	if err := o.outboxEventService.CreatePartition(ctx, name, from, from.Add(partitionSpan)); err != nil {
+		metrics.OutboxPartitionErrorsTotal.Inc()
+		o.log.Error("Failed to create outbox partition",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "partition", Value: name},
+		)
+		return false
+	}
+
+	metrics.OutboxPartitionsCreatedTotal.Inc()
+	o.log.Info("Outbox partition created", logger.Field{Key: "partition", Value: name})
+
+	return true
+}
+
+func (o *Outbox) removePartition(ctx context.Context, partition string) bool {
+	// In polling mode a partition may still hold events that were never
+	// delivered, or failed events that must be kept for FailedRetention. CDC
+	// mode relays from the WAL and leaves every row pending, so its partitions
+	// expire by age alone, the same as its rows under retention.
+	if o.config.RelayMode != RelayModeCDC {
+		retained, err := o.outboxEventService.HasRetainedEvents(ctx, partition, o.config.FailedRetention)
+		if err != nil {
+			metrics.OutboxPartitionErrorsTotal.Inc()
+			o.log.Error("Failed to inspect outbox partition",
+				logger.Field{Key: "error", Value: err.Error()},
+				logger.Field{Key: "partition", Value: partition},
+			)
+			return false
+		}
+		if retained {
+			o.log.Warn("Expired outbox partition still has open or retained failed events, keeping it",
+				logger.Field{Key: "partition", Value: partition},
+			)
+			return false
+		}
+	}
+
+	if err := o.outboxEventService.DetachPartition(ctx, partition, o.config.PartitionDropExpired); err != nil {
+		metrics.OutboxPartitionErrorsTotal.Inc()
+		o.log.Error("Failed to remove outbox partition",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "partition", Value: partition},
+		)
+		return false
+	}
+
+	action := "detached"
+	if o.config.PartitionDropExpired {
+		action = "dropped"
+	}
+	metrics.OutboxPartitionsRemovedTotal.WithLabelValues(action).Inc()
+	o.log.Info("Expired outbox partition removed",
+		logger.Field{Key: "partition", Value: partition},
+		logger.Field{Key: "action", Value: action},
+	)
+
+	return true
+}
+
+func partitionName(from time.Time) string {
+	return partitionPrefix + from.Format(partitionNameLayout)
+}
+
+func parsePartitionName(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	from, err := time.Parse(partitionNameLayout, suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return from, true
+}
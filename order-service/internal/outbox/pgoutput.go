@@ -0,0 +1,228 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Minimal decoder for the streaming replication protocol and the pgoutput
+// plugin (protocol version 1). It only covers what the CDC relay needs:
+// keepalives, transaction boundaries, relations and inserts.
+
+type lsn uint64
+
+func (l lsn) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
+
+const (
+	xLogDataByteID            = 'w'
+	primaryKeepaliveByteID    = 'k'
+	standbyStatusUpdateByteID = 'r'
+)
+
+var (
+	errShortMessage = errors.New("replication message too short")
+
+	postgresEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type xLogData struct {
+	walStart lsn
+	walEnd   lsn
+	data     []byte
+}
+
+func parseXLogData(buf []byte) (*xLogData, error) {
+	if len(buf) < 24 {
+		return nil, errShortMessage
+	}
+
+	return &xLogData{
+		walStart: lsn(binary.BigEndian.Uint64(buf[0:])),
+		walEnd:   lsn(binary.BigEndian.Uint64(buf[8:])),
+		data:     buf[24:],
+	}, nil
+}
+
+type primaryKeepalive struct {
+	walEnd         lsn
+	replyRequested bool
+}
+
+func parsePrimaryKeepalive(buf []byte) (*primaryKeepalive, error) {
+	if len(buf) < 17 {
+		return nil, errShortMessage
+	}
+
+	return &primaryKeepalive{
+		walEnd:         lsn(binary.BigEndian.Uint64(buf[0:])),
+		replyRequested: buf[16] != 0,
+	}, nil
+}
+
+// encodeStandbyStatusUpdate reports pos as written, flushed and applied. For a
+// logical slot the flushed position becomes the slot's confirmed_flush_lsn.
+func encodeStandbyStatusUpdate(pos lsn, now time.Time) []byte {
+	buf := make([]byte, 34)
+	buf[0] = standbyStatusUpdateByteID
+	binary.BigEndian.PutUint64(buf[1:], uint64(pos))
+	binary.BigEndian.PutUint64(buf[9:], uint64(pos))
+	binary.BigEndian.PutUint64(buf[17:], uint64(pos))
+	binary.BigEndian.PutUint64(buf[25:], uint64(now.Sub(postgresEpoch).Microseconds()))
+	return buf
+}
+
+type pgoutputBegin struct {
+	finalLSN lsn
+	xid      uint32
+}
+
+type pgoutputCommit struct {
+	commitLSN lsn
+	endLSN    lsn
+}
+
+type pgoutputRelation struct {
+	id        uint32
+	namespace string
+	name      string
+	columns   []string
+}
+
+type pgoutputInsert struct {
+	relationID uint32
+	values     []*string // nil for NULL and unchanged TOAST values
+}
+
+// decodePgoutput returns nil for message types the relay does not care about.
+func decodePgoutput(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, errShortMessage
+	}
+
+	r := &pgoutputReader{buf: data[1:]}
+
+	var msg any
+	switch data[0] {
+	case 'B':
+		begin := &pgoutputBegin{finalLSN: lsn(r.uint64())}
+		r.uint64() // commit timestamp
+		begin.xid = r.uint32()
+		msg = begin
+
+	case 'C':
+		r.uint8() // flags
+		commit := &pgoutputCommit{commitLSN: lsn(r.uint64()), endLSN: lsn(r.uint64())}
+		r.uint64() // commit timestamp
+		msg = commit
+
+	case 'R':
+		relation := &pgoutputRelation{id: r.uint32(), namespace: r.string(), name: r.string()}
+		r.uint8() // replica identity
+		count := int(r.uint16())
+		for i := 0; i < count && r.err == nil; i++ {
+			r.uint8() // flags
+			relation.columns = append(relation.columns, r.string())
+			r.uint32() // type oid
+			r.uint32() // type modifier
+		}
+		msg = relation
+
+	case 'I':
+		insert := &pgoutputInsert{relationID: r.uint32()}
+		r.uint8() // 'N' new tuple marker
+		insert.values = r.tuple()
+		msg = insert
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode pgoutput message %q: %w", data[0], r.err)
+	}
+
+	return msg, nil
+}
+
+// pgoutputReader reads big-endian fields and remembers the first error.
+type pgoutputReader struct {
+	buf []byte
+	err error
+}
+
+func (r *pgoutputReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = errShortMessage
+		return nil
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *pgoutputReader) uint8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *pgoutputReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *pgoutputReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *pgoutputReader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *pgoutputReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+
+	for i, c := range r.buf {
+		if c == 0 {
+			s := string(r.buf[:i])
+			r.buf = r.buf[i+1:]
+			return s
+		}
+	}
+
+	r.err = errShortMessage
+	return ""
+}
+
+func (r *pgoutputReader) tuple() []*string {
+	count := int(r.uint16())
+	values := make([]*string, 0, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		switch r.uint8() {
+		case 't', 'b':
+			value := string(r.next(int(r.uint32())))
+			values = append(values, &value)
+		default: // 'n' null, 'u' unchanged TOAST
+			values = append(values, nil)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,389 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/tracing"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/rabbitmq"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/schema"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/service"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"gorm.io/gorm"
+)
+
+const (
+	RedriveModeReinsert  = "reinsert"
+	RedriveModeRepublish = "republish"
+
+	redriveRedriven  = "redriven"
+	redriveDuplicate = "duplicate"
+	redriveInvalid   = "invalid"
+	redriveError     = "error"
+)
+
+var errInvalidEnvelope = errors.New("invalid DLQ envelope")
+
+// Redriver takes dead-lettered events off the DLQ and either puts them back
+// into the outbox as pending or publishes them to the events exchange again.
+type Redriver struct {
+	log                logger.Logger
+	outboxEventService service.OutboxEventService
+	rabbitmq           rabbitmq.RabbitMQService
+	publisher          outboxlib.Publisher
+	config             *config.Outbox
+	amqpConfig         *config.AMQP
+}
+
+type RedriverOpts struct {
+	Log                logger.Logger
+	OutboxEventService service.OutboxEventService
+	RabbitMQ           rabbitmq.RabbitMQService
+	Publisher          outboxlib.Publisher
+	Config             *config.Outbox
+	AMQPConfig         *config.AMQP
+}
+
+// RedriveResult counts the DLQ messages handled by a Drain.
+type RedriveResult struct {
+	Redriven   int `json:"redriven"`
+	Duplicates int `json:"duplicates"`
+	Invalid    int `json:"invalid"`
+}
+
+func NewRedriver(opts *RedriverOpts) *Redriver {
+	return &Redriver{
+		log:                opts.Log,
+		outboxEventService: opts.OutboxEventService,
+		rabbitmq:           opts.RabbitMQ,
+		publisher:          opts.Publisher,
+		config:             opts.Config,
+		amqpConfig:         opts.AMQPConfig,
+	}
+}
+
+// Run consumes the DLQ until ctx is done, at most RedriveRate messages per second.
+func (r *Redriver) Run(ctx context.Context) {
+	if err := r.validate(); err != nil {
+		r.log.Error("Outbox redrive disabled", logger.Field{Key: "error", Value: err.Error()})
+		return
+	}
+
+	r.log.Info("Outbox redrive started",
+		logger.Field{Key: "mode", Value: r.config.RedriveMode},
+		logger.Field{Key: "queue", Value: r.amqpConfig.DLQ},
+	)
+
+	delay := reconnectMinBackoff
+
+	for {
+		consumed, err := r.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if consumed {
+			delay = reconnectMinBackoff
+		}
+
+		r.log.Warn("Outbox redrive consumer stopped, restarting",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "backoff_seconds", Value: delay.Seconds()},
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, reconnectMaxBackoff)
+	}
+}
+
+// Drain redrives up to limit messages currently in the DLQ, or all of them
+// when limit is zero, and returns once the queue is empty.
+func (r *Redriver) Drain(ctx context.Context, limit int) (*RedriveResult, error) {
+	result := &RedriveResult{}
+
+	if err := r.validate(); err != nil {
+		return result, err
+	}
+
+	ch, err := r.rabbitmq.NewChannel()
+	if err != nil {
+		return result, err
+	}
+	defer ch.Close()
+
+	session := outboxlib.NewSessionHolder(r.publisher)
+	defer session.Close()
+
+	limiter := newRateLimiter(r.config.RedriveRate)
+	defer limiter.stop()
+
+	for limit == 0 || result.Redriven+result.Duplicates+result.Invalid < limit {
+		d, ok, err := ch.Get(r.amqpConfig.DLQ, false)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			return result, nil
+		}
+
+		outcome, err := r.handleDelivery(ctx, session, d)
+		if err != nil {
+			return result, err
+		}
+		result.add(outcome)
+
+		if err := limiter.wait(ctx); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *Redriver) validate() error {
+	switch r.config.RedriveMode {
+	case RedriveModeReinsert:
+		// Updated rows never show up in the WAL, so the CDC relay would not see them.
+		if r.config.RelayMode == RelayModeCDC {
+			return errors.New("reinsert redrive requires the polling relay")
+		}
+	case RedriveModeRepublish:
+	default:
+		return fmt.Errorf("unknown redrive mode %q", r.config.RedriveMode)
+	}
+
+	return nil
+}
+
+func (r *Redriver) consume(ctx context.Context) (bool, error) {
+	ch, err := r.rabbitmq.NewChannel()
+	if err != nil {
+		return false, err
+	}
+	defer ch.Close()
+
+	session := outboxlib.NewSessionHolder(r.publisher)
+	defer session.Close()
+
+	if err := ch.Qos(1, 0, false); err != nil {
+		return false, err
+	}
+
+	deliveries, err := ch.ConsumeWithContext(ctx, r.amqpConfig.DLQ, "", false, false, false, false, nil)
+	if err != nil {
+		return false, err
+	}
+
+	limiter := newRateLimiter(r.config.RedriveRate)
+	defer limiter.stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case d, ok := <-deliveries:
+			if !ok {
+				return true, errors.New("DLQ consumer channel closed")
+			}
+
+			if _, err := r.handleDelivery(ctx, session, d); err != nil {
+				// The message was requeued, give the failing dependency a moment.
+				select {
+				case <-ctx.Done():
+					return true, ctx.Err()
+				case <-time.After(reconnectMinBackoff):
+				}
+			}
+
+			if err := limiter.wait(ctx); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+// handleDelivery acks the message once it was redriven or found to be a
+// duplicate. Invalid envelopes and events the store rejects for their schema
+// are dropped, anything else is requeued.
+func (r *Redriver) handleDelivery(ctx context.Context, session *outboxlib.SessionHolder, d amqp091.Delivery) (string, error) {
+	outcome, err := r.redrive(ctx, session, d.Body)
+	metrics.OutboxRedriveTotal.WithLabelValues(r.config.RedriveMode, outcome).Inc()
+
+	switch {
+	case errors.Is(err, errInvalidEnvelope),
+		errors.Is(err, schema.ErrInvalidPayload),
+		errors.Is(err, schema.ErrUnknownEventKey):
+		r.log.Error("Dropping invalid DLQ message",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "message_id", Value: d.MessageId},
+			logger.Field{Key: "body", Value: string(d.Body)},
+		)
+		return outcome, d.Nack(false, false)
+
+	case err != nil:
+		r.log.WithContext(ctx).Error("Failed to redrive DLQ message",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "message_id", Value: d.MessageId},
+		)
+		if nackErr := d.Nack(false, true); nackErr != nil {
+			return outcome, nackErr
+		}
+		return outcome, err
+	}
+
+	return outcome, d.Ack(false)
+}
+
+func (r *Redriver) redrive(ctx context.Context, session *outboxlib.SessionHolder, body []byte) (string, error) {
+	var envelope outboxlib.DeadLetterEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return redriveInvalid, fmt.Errorf("%w: %v", errInvalidEnvelope, err)
+	}
+	if envelope.EventID == "" || envelope.EventKey == "" || envelope.FailedAt.IsZero() {
+		return redriveInvalid, fmt.Errorf("%w: missing event_id, event_key or failed_at", errInvalidEnvelope)
+	}
+
+	event := &outboxlib.Event{
+		ID:          envelope.EventID,
+		EventKey:    envelope.EventKey,
+		AggregateID: envelope.AggregateID,
+		Payload:     envelope.EventPayload(),
+		ContentType: envelope.ContentType,
+		SchemaRef:   envelope.SchemaRef,
+		Traceparent: envelope.Traceparent,
+	}
+	if key, ok := r.config.RedriveRoutingKeys[event.EventKey]; ok {
+		event.EventKey = key
+	}
+
+	if event.Traceparent != "" {
+		ctx = tracing.ExtractTraceParent(ctx, event.Traceparent)
+	}
+
+	// A DLQ message is identified by its event ID and failure time. failed_at
+	// is stored with microsecond precision, the envelope's has nanoseconds.
+	record := &model.OutboxRedrive{
+		EventID:    event.ID,
+		FailedAt:   envelope.FailedAt.UTC().Truncate(time.Microsecond),
+		Mode:       r.config.RedriveMode,
+		RoutingKey: event.EventKey,
+	}
+
+	var (
+		redriven bool
+		err      error
+	)
+	if r.config.RedriveMode == RedriveModeRepublish {
+		redriven, err = r.republish(ctx, session, event, record)
+	} else {
+		redriven, err = r.reinsert(ctx, event, record)
+	}
+
+	switch {
+	case err != nil:
+		return redriveError, err
+	case !redriven:
+		return redriveDuplicate, nil
+	}
+
+	r.log.WithContext(ctx).Info("Event redriven from DLQ",
+		logger.Field{Key: "event_id", Value: event.ID},
+		logger.Field{Key: "event_key", Value: event.EventKey},
+		logger.Field{Key: "mode", Value: r.config.RedriveMode},
+	)
+
+	return redriveRedriven, nil
+}
+
+// reinsert records the redrive and resets the event in one transaction.
+func (r *Redriver) reinsert(ctx context.Context, event *outboxlib.Event, record *model.OutboxRedrive) (bool, error) {
+	var recorded bool
+
+	err := r.outboxEventService.Transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		if recorded, err = r.outboxEventService.RecordRedrive(ctx, tx, record); err != nil || !recorded {
+			return err
+		}
+		return r.outboxEventService.Reinsert(ctx, tx, event)
+	})
+
+	return recorded, err
+}
+
+// republish records the redrive only after the broker confirmed the message,
+// so a crash in between leads to a duplicate publish rather than a lost one.
+func (r *Redriver) republish(
+	ctx context.Context,
+	session *outboxlib.SessionHolder,
+	event *outboxlib.Event,
+	record *model.OutboxRedrive,
+) (bool, error) {
+	done, err := r.outboxEventService.IsRedriven(ctx, record.EventID, record.FailedAt)
+	if err != nil || done {
+		return false, err
+	}
+
+	if err := session.Publish(ctx, event); err != nil {
+		return false, err
+	}
+
+	err = r.outboxEventService.Transaction(ctx, func(tx *gorm.DB) error {
+		_, err := r.outboxEventService.RecordRedrive(ctx, tx, record)
+		return err
+	})
+
+	return true, err
+}
+
+func (res *RedriveResult) add(outcome string) {
+	switch outcome {
+	case redriveRedriven:
+		res.Redriven++
+	case redriveDuplicate:
+		res.Duplicates++
+	case redriveInvalid:
+		res.Invalid++
+	}
+}
+
+// rateLimiter spaces out calls to wait; a non-positive rate means unlimited.
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	if perSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{ticker: time.NewTicker(time.Second / time.Duration(perSecond))}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.ticker == nil {
+		return ctx.Err()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+func (l *rateLimiter) stop() {
+	if l.ticker != nil {
+		l.ticker.Stop()
+	}
+}
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
+)
+
+func (o *Outbox) startRetention(ctx context.Context) {
+	ticker := time.NewTicker(o.config.RetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.runRetention(ctx)
+		}
+	}
+}
+
+func (o *Outbox) runRetention(ctx context.Context) {
+	start := time.Now()
+	defer func() {
+		metrics.OutboxRetentionRunDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	if o.config.RelayMode == RelayModeCDC {
+		// CDC relays inserts from the WAL and leaves every row pending, so rows
+		// are expired by age alone. Removing a row never affects its relay, the
+		// slot decodes the insert from the WAL even when it is behind.
+		o.purge(ctx, model.OutboxEventStatusPending, o.config.PublishedRetention)
+	} else {
+		o.purge(ctx, model.OutboxEventStatusPublished, o.config.PublishedRetention)
+		o.purge(ctx, model.OutboxEventStatusFailed, o.config.FailedRetention)
+	}
+	o.purge(ctx, model.OutboxEventStatusCancelled, o.config.FailedRetention)
+	o.purgeDeliveries(ctx, "webhook", o.config.FailedRetention, o.outboxEventService.PurgeWebhookDeliveries)
+	o.purgeDeliveries(ctx, "fanout", o.config.FailedRetention, o.outboxEventService.PurgeOutboxDeliveries)
+
+	if o.config.RetentionArchive {
+		o.reportArchiveSize(ctx)
+	}
+}
+
+// purge removes expired events in bounded batches, each in its own statement,
+// so a large backlog never holds locks or bloats a single transaction.
+func (o *Outbox) purge(ctx context.Context, status string, olderThan time.Duration) {
+	action := "deleted"
+	if o.config.RetentionArchive {
+		action = "archived"
+	}
+
+	var total int64
+	for ctx.Err() == nil {
+		count, err := o.outboxEventService.PurgeEvents(ctx, status, olderThan, o.config.RetentionBatchSize, o.config.RetentionArchive)
+		if err != nil {
+			metrics.OutboxRetentionErrorsTotal.Inc()
+			o.log.Error("Failed to purge outbox events",
+				logger.Field{Key: "error", Value: err.Error()},
+				logger.Field{Key: "status", Value: status},
+			)
+			break
+		}
+
+		total += count
+		metrics.OutboxRetentionPurgedTotal.WithLabelValues(status, action).Add(float64(count))
+
+		if count < int64(o.config.RetentionBatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		o.log.Info("Outbox events purged",
+			logger.Field{Key: "status", Value: status},
+			logger.Field{Key: "action", Value: action},
+			logger.Field{Key: "count", Value: total},
+		)
+	}
+}
+
+// purgeDeliveries keeps webhook and fanout delivery records as long as failed
+// events, their event may be requeued until then.
+func (o *Outbox) purgeDeliveries(
+	ctx context.Context,
+	kind string,
+	olderThan time.Duration,
+	purge func(ctx context.Context, olderThan time.Duration, limit int) (int64, error),
+) {
+	for ctx.Err() == nil {
+		count, err := purge(ctx, olderThan, o.config.RetentionBatchSize)
+		if err != nil {
+			metrics.OutboxRetentionErrorsTotal.Inc()
+			o.log.Error("Failed to purge outbox deliveries",
+				logger.Field{Key: "error", Value: err.Error()},
+				logger.Field{Key: "kind", Value: kind},
+			)
+			return
+		}
+		if count < int64(o.config.RetentionBatchSize) {
+			return
+		}
+	}
+}
+
+func (o *Outbox) reportArchiveSize(ctx context.Context) {
+	size, err := o.outboxEventService.ArchiveSizeBytes(ctx)
+	if err != nil {
+		o.log.Warn("Failed to measure outbox archive size",
+			logger.Field{Key: "error", Value: err.Error()},
+		)
+		return
+	}
+	metrics.OutboxArchiveSizeBytes.Set(float64(size))
+}
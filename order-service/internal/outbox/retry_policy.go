@@ -0,0 +1,27 @@
+package outbox
+
+import (
+	"fmt"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+// NewRetryPolicies builds the default and per event key retry policies. The
+// default is capped exponential retries from MaxRetryCount, RetryDelay and
+// RetryMaxDelay unless RetryPolicy is set. An invalid spec is an error, so a
+// typo fails startup instead of silently changing how events are retried.
+func NewRetryPolicies(cfg *config.Outbox) (*outboxlib.RetryPolicies, error) {
+	defaultSpec := cfg.RetryPolicy
+	if defaultSpec == "" {
+		defaultSpec = fmt.Sprintf("%s:%s:%s:%d",
+			outboxlib.RetryPolicyExponential, cfg.RetryDelay, cfg.RetryMaxDelay, cfg.MaxRetryCount)
+	}
+
+	policies, err := outboxlib.NewRetryPolicies(defaultSpec, cfg.RetryPolicies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbox retry policy: %w", err)
+	}
+
+	return policies, nil
+}
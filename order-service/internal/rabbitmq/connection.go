@@ -4,13 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/metrics"
 )
 
+const (
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+var errClosing = errors.New("rabbitmq: client is closing")
+
 func (r *RabbitMQ) Health() error {
-	if r.Conn == nil || r.Conn.IsClosed() {
+	conn := r.conn()
+	if conn == nil || conn.IsClosed() {
 		return errors.New("RabbitMQ healthcheck failed")
 	}
 
@@ -18,26 +28,65 @@ func (r *RabbitMQ) Health() error {
 }
 
 func (r *RabbitMQ) Close() error {
-	if r.Conn != nil && !r.Conn.IsClosed() {
-		return r.Conn.Close()
+	r.mu.Lock()
+	r.closing = true
+	conn := r.Conn
+	r.mu.Unlock()
+
+	metrics.RabbitMQConnected.Set(0)
+
+	if conn != nil && !conn.IsClosed() {
+		return conn.Close()
 	}
 	return nil
 }
 
-func (r *RabbitMQ) connect(ctx context.Context) error {
-	var err error
+func (r *RabbitMQ) conn() *amqp091.Connection {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.Conn
+}
 
+func (r *RabbitMQ) isClosing() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.closing
+}
+
+func (r *RabbitMQ) connect(ctx context.Context) error {
 	address := fmt.Sprintf("amqp://%s:%s@%s:%d", r.Config.Username, r.Config.Password, r.Config.Host, r.Config.Port)
-	r.Conn, err = amqp091.Dial(address)
+	conn, err := amqp091.Dial(address)
 	if err != nil {
 		r.Log.Error("RabbitMQ connection error", logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 
+	if err := r.declareTopology(conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	r.mu.Lock()
+	if r.closing {
+		r.mu.Unlock()
+		_ = conn.Close()
+		return errClosing
+	}
+	r.Conn = conn
+	r.mu.Unlock()
+
+	metrics.RabbitMQConnected.Set(1)
 	r.Log.Info("RabbitMQ connected")
 
-	channel, err := r.NewChannel()
+	return nil
+}
+
+// declareTopology is idempotent, so it runs again after every reconnect in
+// case the broker lost its definitions.
+func (r *RabbitMQ) declareTopology(conn *amqp091.Connection) error {
+	channel, err := conn.Channel()
 	if err != nil {
+		r.Log.Error("RabbitMQ channel error", logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 	defer channel.Close()
@@ -51,3 +100,60 @@ func (r *RabbitMQ) connect(ctx context.Context) error {
 
 	return nil
 }
+
+// watch reconnects with capped backoff whenever the connection is lost, until
+// ctx is done or Close is called. Channels opened on the old connection are
+// dead afterwards, their owners notice through NotifyClose and reopen them.
+func (r *RabbitMQ) watch(ctx context.Context) {
+	for {
+		closed := r.conn().NotifyClose(make(chan *amqp091.Error, 1))
+
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-closed:
+			if r.isClosing() {
+				return
+			}
+
+			metrics.RabbitMQConnected.Set(0)
+			reason := "connection closed"
+			if err != nil {
+				reason = err.Error()
+			}
+			r.Log.Warn("RabbitMQ connection lost, reconnecting", logger.Field{Key: "error", Value: reason})
+		}
+
+		if !r.reconnect(ctx) {
+			return
+		}
+	}
+}
+
+func (r *RabbitMQ) reconnect(ctx context.Context) bool {
+	delay := reconnectMinBackoff
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+
+		err := r.connect(ctx)
+		if err == nil {
+			metrics.RabbitMQReconnectsTotal.WithLabelValues("success").Inc()
+			return true
+		}
+		if errors.Is(err, errClosing) {
+			return false
+		}
+
+		metrics.RabbitMQReconnectsTotal.WithLabelValues("error").Inc()
+		delay = min(delay*2, reconnectMaxBackoff)
+		r.Log.Warn("RabbitMQ reconnect failed",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "backoff_seconds", Value: delay.Seconds()},
+		)
+	}
+}
@@ -6,8 +6,9 @@ func (r *RabbitMQ) initExchanges(ch *amqp091.Channel) error {
 	exchanges := []struct {
 		Name string
 		Kind string
+		Args amqp091.Table
 	}{
-		{Name: r.Config.Exchange, Kind: "topic"},
+		{Name: r.Config.Exchange, Kind: "topic", Args: r.exchangeArgs()},
 		{Name: r.Config.DLX, Kind: "direct"},
 	}
 
@@ -19,12 +20,62 @@ func (r *RabbitMQ) initExchanges(ch *amqp091.Channel) error {
 			false,
 			false,
 			false,
-			nil,
+			exchange.Args,
 		)
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return r.initAlternateExchange(ch)
+}
+
+func (r *RabbitMQ) exchangeArgs() amqp091.Table {
+	if r.Config.AlternateExchange == "" {
+		return nil
+	}
+
+	return amqp091.Table{"alternate-exchange": r.Config.AlternateExchange}
+}
+
+// initAlternateExchange declares a fanout exchange with a queue of the same name
+// that collects every message the events exchange could not route. When it is
+// enabled the broker no longer returns mandatory messages as unroutable.
+func (r *RabbitMQ) initAlternateExchange(ch *amqp091.Channel) error {
+	if r.Config.AlternateExchange == "" {
+		return nil
+	}
+
+	err := ch.ExchangeDeclare(
+		r.Config.AlternateExchange,
+		"fanout",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.QueueDeclare(
+		r.Config.AlternateExchange,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	return ch.QueueBind(
+		r.Config.AlternateExchange,
+		"",
+		r.Config.AlternateExchange,
+		false,
+		nil,
+	)
 }
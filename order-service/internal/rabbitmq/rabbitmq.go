@@ -2,6 +2,7 @@ package rabbitmq
 
 import (
 	"context"
+	"sync"
 
 	"github.com/rabbitmq/amqp091-go"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
@@ -12,13 +13,15 @@ type RabbitMQService interface {
 	Health() error
 	Close() error
 	NewChannel() (*amqp091.Channel, error)
-	Publish(ctx context.Context, opts *PublishOpts) error
 }
 
 type RabbitMQ struct {
 	Config *config.AMQP
 	Conn   *amqp091.Connection
 	Log    logger.Logger
+
+	mu      sync.RWMutex // guards Conn and closing
+	closing bool
 }
 
 type Opts struct {
@@ -34,13 +37,18 @@ func NewRabbitMQ(ctx context.Context, opts *Opts) (RabbitMQService, error) {
 	if err := b.connect(ctx); err != nil {
 		return nil, err
 	}
+	go b.watch(ctx)
 
 	return b, nil
 }
 
 func (r *RabbitMQ) NewChannel() (*amqp091.Channel, error) {
-	c, err := r.Conn.Channel()
+	conn := r.conn()
+	if conn == nil {
+		return nil, amqp091.ErrClosed
+	}
 
+	c, err := conn.Channel()
 	if err != nil {
 		r.Log.Error("RabbitMQ channel error", logger.Field{Key: "error", Value: err.Error()})
 		return nil, err
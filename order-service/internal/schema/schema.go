@@ -0,0 +1,140 @@
+// Package schema is the registry of the JSON Schemas events are published
+// with. Schemas are embedded from schemas/<event key>/v<version>.json, a new
+// version is added as a new file next to the previous ones.
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed schemas
+var schemas embed.FS
+
+var (
+	ErrUnknownEventKey = errors.New("no schema registered for event key")
+	ErrInvalidPayload  = errors.New("payload does not match schema")
+)
+
+// ValidationError describes why a payload was rejected.
+type ValidationError struct {
+	EventKey string
+	Version  int
+	Err      error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s v%d: %v", ErrInvalidPayload, e.EventKey, e.Version, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidPayload
+}
+
+type Registry struct {
+	schemas map[string]map[int]*jsonschema.Schema
+	latest  map[string]int
+}
+
+// NewRegistry compiles the embedded schemas.
+func NewRegistry() (*Registry, error) {
+	sub, err := fs.Sub(schemas, "schemas")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRegistryFS(sub)
+}
+
+// NewRegistryFS compiles the schemas of fsys, laid out as
+// <event key>/v<version>.json.
+func NewRegistryFS(fsys fs.FS) (*Registry, error) {
+	r := &Registry{
+		schemas: map[string]map[int]*jsonschema.Schema{},
+		latest:  map[string]int{},
+	}
+	compiler := jsonschema.NewCompiler()
+
+	files, err := fs.Glob(fsys, "*/v*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		eventKey := path.Dir(file)
+		version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path.Base(file), "v"), ".json"))
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("schema %s: file name must be v<version>.json", file)
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		url := "schema://" + file
+		if err := compiler.AddResource(url, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", file, err)
+		}
+		compiled, err := compiler.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", file, err)
+		}
+
+		if r.schemas[eventKey] == nil {
+			r.schemas[eventKey] = map[int]*jsonschema.Schema{}
+		}
+		r.schemas[eventKey][version] = compiled
+		r.latest[eventKey] = max(r.latest[eventKey], version)
+	}
+
+	return r, nil
+}
+
+// Validate checks payload against the latest version of the event key's
+// schema, which is what producers write.
+func (r *Registry) Validate(eventKey string, payload []byte) error {
+	version, ok := r.latest[eventKey]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownEventKey, eventKey)
+	}
+
+	return r.ValidateVersion(eventKey, version, payload)
+}
+
+func (r *Registry) ValidateVersion(eventKey string, version int, payload []byte) error {
+	compiled, ok := r.schemas[eventKey][version]
+	if !ok {
+		return fmt.Errorf("%w %q v%d", ErrUnknownEventKey, eventKey, version)
+	}
+
+	var v any
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return &ValidationError{EventKey: eventKey, Version: version, Err: err}
+	}
+	if err := compiled.Validate(v); err != nil {
+		return &ValidationError{EventKey: eventKey, Version: version, Err: err}
+	}
+
+	return nil
+}
+
+// Versions returns the registered versions of an event key in ascending order.
+func (r *Registry) Versions(eventKey string) []int {
+	versions := make([]int, 0, len(r.schemas[eventKey]))
+	for version := range r.schemas[eventKey] {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+
+	return versions
+}
@@ -17,18 +17,25 @@ type HealthStatus struct {
 
 type DependencyHealthCheck func(ctx context.Context) error
 
+// HealthDetail adds informational state to the readiness details without
+// affecting the overall status.
+type HealthDetail func(ctx context.Context) string
+
 type healthService struct {
-	ready  atomic.Bool
-	checks map[string]DependencyHealthCheck
+	ready   atomic.Bool
+	checks  map[string]DependencyHealthCheck
+	details map[string]HealthDetail
 }
 
 type HealthServiceOpts struct {
-	Checks map[string]DependencyHealthCheck
+	Checks  map[string]DependencyHealthCheck
+	Details map[string]HealthDetail
 }
 
 func NewHealthService(opts *HealthServiceOpts) HealthService {
 	h := &healthService{
-		checks: opts.Checks,
+		checks:  opts.Checks,
+		details: opts.Details,
 	}
 	h.ready.Store(true)
 	return h
@@ -57,5 +64,9 @@ func (h *healthService) Check(ctx context.Context) HealthStatus {
 		}
 	}
 
+	for name, fn := range h.details {
+		status.Details[name] = fn(ctx)
+	}
+
 	return status
 }
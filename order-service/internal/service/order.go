@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/observability/tracing"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	"gorm.io/gorm"
@@ -18,15 +21,15 @@ type OrderService interface {
 }
 
 type orderService struct {
-	db                 *gorm.DB
-	log                logger.Logger
-	outboxEventService OutboxEventService
+	db          *gorm.DB
+	log         logger.Logger
+	outboxStore outboxlib.Store
 }
 
 type OrderServiceOpts struct {
-	DB                 database.DatabaseService
-	Log                logger.Logger
-	OutboxEventService OutboxEventService
+	DB          database.DatabaseService
+	Log         logger.Logger
+	OutboxStore outboxlib.Store
 }
 
 type CreateOrder struct {
@@ -36,9 +39,9 @@ type CreateOrder struct {
 
 func NewOrderService(opts *OrderServiceOpts) OrderService {
 	return &orderService{
-		db:                 opts.DB.DB(),
-		log:                opts.Log,
-		outboxEventService: opts.OutboxEventService,
+		db:          opts.DB.DB(),
+		log:         opts.Log,
+		outboxStore: opts.OutboxStore,
 	}
 }
 
@@ -63,23 +66,25 @@ func (o *orderService) Create(
 		carrier := propagation.MapCarrier{}
 		otel.GetTextMapPropagator().Inject(ctx, carrier)
 
-		outboxEvent := &model.OutboxEvent{
-			ID:       uuid.NewString(),
-			EventKey: "order.created",
-			Payload: model.JSONB{
-				"id":         order.ID,
-				"product_id": req.ProductID,
-				"quantity":   req.Quantity,
-			},
-			Status:      model.OutboxEventStatusPending,
-			Traceparent: carrier["traceparent"],
+		payload, err := json.Marshal(map[string]interface{}{
+			"id":         order.ID,
+			"product_id": req.ProductID,
+			"quantity":   req.Quantity,
+		})
+		if err != nil {
+			return err
 		}
 
-		if err := o.outboxEventService.Create(ctx, tx, outboxEvent); err != nil {
-			return err
+		outboxEvent := &outboxlib.Event{
+			ID:          uuid.NewString(),
+			EventKey:    "order.created",
+			AggregateID: fmt.Sprintf("order:%d", order.ID),
+			Payload:     payload,
+			Traceparent: carrier["traceparent"],
 		}
 
-		return nil
+		// the store validates the payload against its schema
+		return o.outboxStore.Insert(ctx, tx, outboxEvent)
 	})
 
 	if err != nil {
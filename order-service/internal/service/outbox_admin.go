@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	"gorm.io/gorm"
+)
+
+var ErrEmptyFilter = errors.New("filter must select at least one event")
+
+// OutboxEventFilter selects events for listing and bulk actions. Zero values
+// are ignored.
+type OutboxEventFilter struct {
+	IDs           []string
+	Status        string
+	EventKey      string
+	FailureReason string // case-insensitive substring
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+}
+
+// OutboxEventStat is the number of events sharing a status, event key and age bucket.
+type OutboxEventStat struct {
+	Status   string `json:"status"`
+	EventKey string `json:"event_key"`
+	Age      string `json:"age"`
+	Count    int64  `json:"count"`
+}
+
+// AdminAction identifies who performed a manual action and why.
+type AdminAction struct {
+	Actor  string
+	Reason string
+	DryRun bool // only count the events the action would affect
+}
+
+func (o *outboxEventService) ListEvents(
+	ctx context.Context,
+	filter *OutboxEventFilter,
+	limit, offset int,
+) ([]*model.OutboxEvent, int64, error) {
+	var (
+		events []*model.OutboxEvent
+		total  int64
+	)
+
+	if err := applyFilter(o.db.WithContext(ctx).Model(&model.OutboxEvent{}), filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := applyFilter(o.db.WithContext(ctx), filter).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&events).Error
+
+	return events, total, err
+}
+
+func (o *outboxEventService) GetEvent(ctx context.Context, id string) (*model.OutboxEvent, error) {
+	var event model.OutboxEvent
+
+	if err := o.db.WithContext(ctx).Where("id = ?", id).Take(&event).Error; err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (o *outboxEventService) Stats(ctx context.Context) ([]*OutboxEventStat, error) {
+	var stats []*OutboxEventStat
+
+	err := o.db.WithContext(ctx).
+		Raw(`
+			SELECT status, event_key, age, COUNT(*) AS count
+			FROM (
+				SELECT
+					status,
+					event_key,
+					CASE
+						WHEN created_at > NOW() - INTERVAL '1 minute' THEN '<1m'
+						WHEN created_at > NOW() - INTERVAL '1 hour' THEN '<1h'
+						WHEN created_at > NOW() - INTERVAL '1 day' THEN '<1d'
+						WHEN created_at > NOW() - INTERVAL '7 days' THEN '<7d'
+						ELSE '>=7d'
+					END AS age,
+					CASE
+						WHEN created_at > NOW() - INTERVAL '1 minute' THEN 0
+						WHEN created_at > NOW() - INTERVAL '1 hour' THEN 1
+						WHEN created_at > NOW() - INTERVAL '1 day' THEN 2
+						WHEN created_at > NOW() - INTERVAL '7 days' THEN 3
+						ELSE 4
+					END AS age_order
+				FROM outbox_events
+			) e
+			GROUP BY status, event_key, age, age_order
+			ORDER BY status, event_key, age_order`).
+		Scan(&stats).Error
+
+	return stats, err
+}
+
+// RequeueEvents moves failed events matching the filter back to pending with a
+// fresh retry budget.
+func (o *outboxEventService) RequeueEvents(
+	ctx context.Context,
+	filter *OutboxEventFilter,
+	action *AdminAction,
+) (int64, error) {
+	update := map[string]interface{}{
+		"status":         model.OutboxEventStatusPending,
+		"retry_count":    0,
+		"next_retry_at":  nil,
+		"locked_at":      nil,
+		"locked_by":      nil,
+		"failure_reason": nil,
+		"failed_at":      nil,
+	}
+
+	affected, err := o.adminUpdate(ctx, model.OutboxAdminActionRequeue, model.OutboxEventStatusFailed, filter, action, update)
+	if err != nil || affected == 0 || !o.config.NotifyEnabled {
+		return affected, err
+	}
+
+	// Wake the relay instead of waiting for the next poll.
+	return affected, o.db.WithContext(ctx).Exec("SELECT pg_notify(?, '')", o.config.NotifyChannel).Error
+}
+
+// CancelEvents marks pending events matching the filter as cancelled so they
+// are never published.
+func (o *outboxEventService) CancelEvents(
+	ctx context.Context,
+	filter *OutboxEventFilter,
+	action *AdminAction,
+) (int64, error) {
+	update := map[string]interface{}{
+		"status":        model.OutboxEventStatusCancelled,
+		"next_retry_at": nil,
+	}
+
+	return o.adminUpdate(ctx, model.OutboxAdminActionCancel, model.OutboxEventStatusPending, filter, action, update)
+}
+
+// adminUpdate applies update to events in fromStatus matching the filter and
+// records the action in outbox_admin_audit within the same transaction. A dry
+// run counts the same events and changes nothing.
+func (o *outboxEventService) adminUpdate(
+	ctx context.Context,
+	name string,
+	fromStatus string,
+	filter *OutboxEventFilter,
+	action *AdminAction,
+	update map[string]interface{},
+) (int64, error) {
+	if filter.empty() {
+		return 0, ErrEmptyFilter
+	}
+
+	var affected int64
+
+	if action.DryRun {
+		err := applyFilter(o.db.WithContext(ctx).Model(&model.OutboxEvent{}), filter).
+			Where("status = ?", fromStatus).
+			Count(&affected).Error
+		return affected, err
+	}
+
+	err := withTransaction(ctx, o.db, func(tx *gorm.DB) error {
+		result := applyFilter(tx.Model(&model.OutboxEvent{}), filter).
+			Where("status = ?", fromStatus).
+			UpdateColumns(update)
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+
+		return tx.Create(&model.OutboxAdminAudit{
+			Actor:    action.Actor,
+			Action:   name,
+			Reason:   action.Reason,
+			Filter:   filter.toJSONB(),
+			Affected: affected,
+		}).Error
+	})
+
+	return affected, err
+}
+
+func applyFilter(db *gorm.DB, filter *OutboxEventFilter) *gorm.DB {
+	if len(filter.IDs) > 0 {
+		db = db.Where("id IN ?", filter.IDs)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.EventKey != "" {
+		db = db.Where("event_key = ?", filter.EventKey)
+	}
+	if filter.FailureReason != "" {
+		db = db.Where("failure_reason ILIKE ?", "%"+filter.FailureReason+"%")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		db = db.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		db = db.Where("created_at < ?", filter.CreatedTo)
+	}
+
+	return db
+}
+
+// empty reports whether the filter would match every event in a status.
+func (f *OutboxEventFilter) empty() bool {
+	return len(f.IDs) == 0 &&
+		f.EventKey == "" &&
+		f.FailureReason == "" &&
+		f.CreatedFrom.IsZero() &&
+		f.CreatedTo.IsZero()
+}
+
+func (f *OutboxEventFilter) toJSONB() model.JSONB {
+	j := model.JSONB{}
+	if len(f.IDs) > 0 {
+		j["ids"] = f.IDs
+	}
+	if f.Status != "" {
+		j["status"] = f.Status
+	}
+	if f.EventKey != "" {
+		j["event_key"] = f.EventKey
+	}
+	if f.FailureReason != "" {
+		j["failure_reason"] = f.FailureReason
+	}
+	if !f.CreatedFrom.IsZero() {
+		j["created_from"] = f.CreatedFrom
+	}
+	if !f.CreatedTo.IsZero() {
+		j["created_to"] = f.CreatedTo
+	}
+	return j
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"gorm.io/gorm/clause"
+)
+
+// Delivered returns the fanout destinations that confirmed the event.
+func (o *outboxEventService) Delivered(ctx context.Context, event *outboxlib.Event) ([]string, error) {
+	var destinations []string
+
+	err := o.db.WithContext(ctx).
+		Model(&model.OutboxDelivery{}).
+		Where("event_id = ?", event.ID).
+		Pluck("destination", &destinations).Error
+
+	return destinations, err
+}
+
+func (o *outboxEventService) MarkDelivered(ctx context.Context, event *outboxlib.Event, destination string) error {
+	return o.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.OutboxDelivery{EventID: event.ID, Destination: destination}).Error
+}
+
+// PurgeOutboxDeliveries removes one batch of fanout delivery records older
+// than olderThan. They only matter while their event may still be retried.
+func (o *outboxEventService) PurgeOutboxDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	result := o.db.WithContext(ctx).Exec(`
+		DELETE FROM outbox_deliveries
+		WHERE ctid IN (
+			SELECT ctid FROM outbox_deliveries
+			WHERE delivered_at < NOW() - make_interval(secs => ?)
+			LIMIT ?
+		)`,
+		olderThan.Seconds(), limit,
+	)
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"gorm.io/gorm"
+)
+
+// OutboxKeyUsage is the number of encrypted payloads whose data key is
+// wrapped with a keyring key.
+type OutboxKeyUsage struct {
+	KeyID    string `json:"key_id"`
+	Count    int64  `json:"count"`
+	Shredded int64  `json:"shredded"`
+}
+
+// RewrapFunc returns encryption with its data key wrapped by another key.
+type RewrapFunc func(encryption *outboxlib.Encryption) (*outboxlib.Encryption, error)
+
+// ErrNoArchive is returned for archive operations on a partitioned outbox,
+// schema_partitioned.sql has no outbox_events_archive table.
+var ErrNoArchive = errors.New("partitioned outboxes have no archive")
+
+// encryptionTable returns outbox_events, or outbox_events_archive when
+// archive is set.
+func (o *outboxEventService) encryptionTable(archive bool) (string, error) {
+	if !archive {
+		return "outbox_events", nil
+	}
+	if o.config.PartitioningEnabled {
+		return "", ErrNoArchive
+	}
+	return "outbox_events_archive", nil
+}
+
+func (o *outboxEventService) KeyUsage(ctx context.Context, archive bool) ([]*OutboxKeyUsage, error) {
+	table, err := o.encryptionTable(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage []*OutboxKeyUsage
+
+	err = o.db.WithContext(ctx).
+		Raw(`
+			SELECT
+				key_id,
+				COUNT(*) AS count,
+				COUNT(*) FILTER (WHERE wrapped_key IS NULL) AS shredded
+			FROM ` + table + `
+			WHERE key_id <> ''
+			GROUP BY key_id
+			ORDER BY key_id`).
+		Scan(&usage).Error
+
+	return usage, err
+}
+
+// RewrapKeys rewraps one batch of data keys that are wrapped with one of
+// keyIDs and returns how many were rewrapped. Payloads are left as they are.
+func (o *outboxEventService) RewrapKeys(
+	ctx context.Context,
+	archive bool,
+	keyIDs []string,
+	limit int,
+	rewrap RewrapFunc,
+) (int64, error) {
+	if len(keyIDs) == 0 {
+		return 0, nil
+	}
+
+	type row struct {
+		ID              string
+		KeyID           string
+		WrappedKey      []byte
+		EncryptionScope string
+	}
+
+	table, err := o.encryptionTable(archive)
+	if err != nil {
+		return 0, err
+	}
+
+	var rewrapped int64
+	err = o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []*row
+		err := tx.
+			Raw(`
+				SELECT id, key_id, wrapped_key, encryption_scope
+				FROM `+table+`
+				WHERE key_id IN ? AND wrapped_key IS NOT NULL
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED`,
+				keyIDs, limit,
+			).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			encryption, err := rewrap(&outboxlib.Encryption{
+				KeyID:      r.KeyID,
+				WrappedKey: r.WrappedKey,
+				Scope:      r.EncryptionScope,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = tx.
+				Exec("UPDATE "+table+" SET key_id = ?, wrapped_key = ? WHERE id = ?", encryption.KeyID, encryption.WrappedKey, r.ID).
+				Error
+			if err != nil {
+				return err
+			}
+			rewrapped++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rewrapped, nil
+}
+
+// ShredKey deletes every data key wrapped with keyID, which makes their
+// payloads unreadable even if the keyring key survived in a backup.
+func (o *outboxEventService) ShredKey(ctx context.Context, archive bool, keyID string) (int64, error) {
+	table, err := o.encryptionTable(archive)
+	if err != nil {
+		return 0, err
+	}
+
+	result := o.db.WithContext(ctx).Exec(
+		"UPDATE "+table+" SET wrapped_key = NULL WHERE key_id = ? AND wrapped_key IS NOT NULL",
+		keyID,
+	)
+
+	return result.RowsAffected, result.Error
+}
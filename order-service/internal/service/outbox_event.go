@@ -2,147 +2,216 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
 	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
 	"gorm.io/gorm"
 )
 
 type OutboxEventService interface {
-	Create(ctx context.Context, tx *gorm.DB, row *model.OutboxEvent) error
-	ClaimEvents(ctx context.Context, workerID string, limit int) ([]*model.OutboxEvent, error)
-	UpdateStateIfInProgress(ctx context.Context, eventID string, update map[string]interface{}) (bool, error)
-	CountBacklog(ctx context.Context) (int64, error)
-	CountWaitingRetry(ctx context.Context) (int64, error)
+	PurgeEvents(ctx context.Context, status string, olderThan time.Duration, limit int, archive bool) (int64, error)
+	PurgeWebhookDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int64, error)
+	PurgeOutboxDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int64, error)
+	ArchiveSizeBytes(ctx context.Context) (int64, error)
+	CreatePartition(ctx context.Context, name string, from, to time.Time) error
+	ListPartitions(ctx context.Context) ([]string, error)
+	DatabaseTime(ctx context.Context) (time.Time, error)
+	HasRetainedEvents(ctx context.Context, partition string, failedRetention time.Duration) (bool, error)
+	DetachPartition(ctx context.Context, partition string, drop bool) error
+	ListEvents(ctx context.Context, filter *OutboxEventFilter, limit, offset int) ([]*model.OutboxEvent, int64, error)
+	GetEvent(ctx context.Context, id string) (*model.OutboxEvent, error)
+	RequeueEvents(ctx context.Context, filter *OutboxEventFilter, action *AdminAction) (int64, error)
+	CancelEvents(ctx context.Context, filter *OutboxEventFilter, action *AdminAction) (int64, error)
+	Stats(ctx context.Context) ([]*OutboxEventStat, error)
+	Delivered(ctx context.Context, event *outboxlib.Event) ([]string, error)
+	MarkDelivered(ctx context.Context, event *outboxlib.Event, destination string) error
+	IsRedriven(ctx context.Context, eventID string, failedAt time.Time) (bool, error)
+	RecordRedrive(ctx context.Context, tx *gorm.DB, redrive *model.OutboxRedrive) (bool, error)
+	Reinsert(ctx context.Context, tx *gorm.DB, event *outboxlib.Event) error
+	KeyUsage(ctx context.Context, archive bool) ([]*OutboxKeyUsage, error)
+	RewrapKeys(ctx context.Context, archive bool, keyIDs []string, limit int, rewrap RewrapFunc) (int64, error)
+	ShredKey(ctx context.Context, archive bool, keyID string) (int64, error)
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
 }
 
 type outboxEventService struct {
-	db  *gorm.DB
-	log logger.Logger
+	db     *gorm.DB
+	log    logger.Logger
+	store  outboxlib.Store
+	config *config.Outbox
 }
 
 type OutboxEventServiceOpts struct {
-	DB  database.DatabaseService
-	Log logger.Logger
+	DB     database.DatabaseService
+	Log    logger.Logger
+	Store  outboxlib.Store
+	Config *config.Outbox
 }
 
 func NewOutboxEventService(opts *OutboxEventServiceOpts) OutboxEventService {
 	return &outboxEventService{
-		db:  opts.DB.DB(),
-		log: opts.Log,
+		db:     opts.DB.DB(),
+		log:    opts.Log,
+		store:  opts.Store,
+		config: opts.Config,
 	}
 }
 
-func (o *outboxEventService) Create(ctx context.Context, tx *gorm.DB, row *model.OutboxEvent) error {
-	return tx.WithContext(ctx).Save(row).Error
-}
+// archiveColumns are copied into outbox_events_archive by name, so the order
+// of columns in either table does not matter.
+const archiveColumns = `id, event_key, aggregate_id, sequence, payload, content_type, schema_ref,
+	key_id, wrapped_key, encryption_scope, status, retry_count, next_retry_at, locked_at, locked_by,
+	failure_reason, failed_at, traceparent, created_at`
 
-func (o *outboxEventService) UpdateStateIfInProgress(
+// PurgeEvents removes one batch of events in the given status created before
+// olderThan, optionally moving them into outbox_events_archive in the same
+// statement. It returns the number of rows removed.
+func (o *outboxEventService) PurgeEvents(
 	ctx context.Context,
-	eventID string,
-	update map[string]interface{},
-) (bool, error) {
-
-	result := o.db.WithContext(ctx).
-		Model(&model.OutboxEvent{}).
-		Where("id = ? AND status = ?", eventID, model.OutboxEventStatusInProgress).
-		UpdateColumns(update)
-
-	if result.Error != nil {
-		return false, result.Error
+	status string,
+	olderThan time.Duration,
+	limit int,
+	archive bool,
+) (int64, error) {
+	batch := `
+		SELECT id
+		FROM outbox_events
+		WHERE
+			status = ?
+			AND created_at < NOW() - make_interval(secs => ?)
+		ORDER BY created_at
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`
+
+	query := `DELETE FROM outbox_events WHERE id IN (` + batch + `)`
+	if archive {
+		query = `
+			WITH purged AS (
+				DELETE FROM outbox_events
+				WHERE id IN (` + batch + `)
+				RETURNING ` + archiveColumns + `
+			)
+			INSERT INTO outbox_events_archive (` + archiveColumns + `, archived_at)
+			SELECT ` + archiveColumns + `, NOW() FROM purged`
 	}
 
-	return result.RowsAffected > 0, nil
+	result := o.db.WithContext(ctx).Exec(query, status, olderThan.Seconds(), limit)
+
+	return result.RowsAffected, result.Error
 }
 
-func (o *outboxEventService) ClaimEvents(
-	ctx context.Context,
-	workerID string,
-	limit int,
-) ([]*model.OutboxEvent, error) {
-	var events []*model.OutboxEvent
-
-	query := `
-		UPDATE outbox_events
-		SET
-			status = ?,
-			locked_at = NOW(),
-			locked_by = ?
-		WHERE id IN (
-				SELECT id
-				FROM outbox_events
-				WHERE
-					(
-						status = ?
-						OR (
-							status = ?
-							AND locked_at < NOW() - INTERVAL '30 seconds'
-						)
-					)
-					AND (
-						next_retry_at IS NULL
-						OR next_retry_at <= NOW()
-					)
-				ORDER BY created_at
-				LIMIT ?
-				FOR UPDATE SKIP LOCKED
-		)
-		RETURNING *`
+func (o *outboxEventService) ArchiveSizeBytes(ctx context.Context) (int64, error) {
+	var size int64
 
 	err := o.db.WithContext(ctx).
-		Raw(query,
-			model.OutboxEventStatusInProgress,
-			workerID,
-			model.OutboxEventStatusPending,
-			model.OutboxEventStatusInProgress,
-			limit,
-		).
-		Scan(&events).Error
+		Raw("SELECT pg_total_relation_size('outbox_events_archive')").
+		Scan(&size).Error
+
+	return size, err
+}
+
+func (o *outboxEventService) CreatePartition(ctx context.Context, name string, from, to time.Time) error {
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s PARTITION OF outbox_events FOR VALUES FROM ('%s') TO ('%s')",
+		quoteIdentifier(name),
+		from.Format(time.DateOnly),
+		to.Format(time.DateOnly),
+	)
 
-	return events, err
+	return o.db.WithContext(ctx).Exec(query).Error
 }
 
-func (o *outboxEventService) CountBacklog(ctx context.Context) (int64, error) {
-	var count int64
+func (o *outboxEventService) ListPartitions(ctx context.Context) ([]string, error) {
+	var partitions []string
 
 	err := o.db.WithContext(ctx).
-		Model(&model.OutboxEvent{}).
-		Where(`
-			(
-				status = ?
+		Raw(`
+			SELECT c.relname
+			FROM pg_inherits i
+			JOIN pg_class c ON c.oid = i.inhrelid
+			WHERE i.inhparent = 'outbox_events'::regclass
+			ORDER BY c.relname`).
+		Scan(&partitions).Error
+
+	return partitions, err
+}
+
+// DatabaseTime returns the database's LOCALTIMESTAMP, the clock created_at is
+// filled from. Its wall clock is returned as UTC.
+func (o *outboxEventService) DatabaseTime(ctx context.Context) (time.Time, error) {
+	var now time.Time
+
+	err := o.db.WithContext(ctx).Raw("SELECT LOCALTIMESTAMP").Scan(&now).Error
+
+	return now, err
+}
+
+// HasRetainedEvents reports whether a partition still holds events that were
+// never delivered, or failed and cancelled events younger than failedRetention.
+func (o *outboxEventService) HasRetainedEvents(ctx context.Context, partition string, failedRetention time.Duration) (bool, error) {
+	var retained bool
+
+	query := fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1 FROM %s
+			WHERE
+				status IN (?, ?)
 				OR (
-					status = ?
-					AND locked_at < NOW() - INTERVAL '30 seconds'
+					status IN (?, ?)
+					AND created_at >= NOW() - make_interval(secs => ?)
 				)
-			)
-			AND (
-				next_retry_at IS NULL
-				OR next_retry_at <= NOW()
-			)`,
+		)`,
+		quoteIdentifier(partition),
+	)
+	err := o.db.WithContext(ctx).
+		Raw(query,
 			model.OutboxEventStatusPending,
 			model.OutboxEventStatusInProgress,
+			model.OutboxEventStatusFailed,
+			model.OutboxEventStatusCancelled,
+			failedRetention.Seconds(),
 		).
-		Count(&count).Error
+		Scan(&retained).Error
 
-	return count, err
+	return retained, err
 }
 
-func (o *outboxEventService) CountWaitingRetry(ctx context.Context) (int64, error) {
-	var count int64
+// DetachPartition detaches a partition from outbox_events and optionally drops
+// it, which frees the space without the bloat of deleting its rows.
+func (o *outboxEventService) DetachPartition(ctx context.Context, partition string, drop bool) error {
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE outbox_events DETACH PARTITION " + quoteIdentifier(partition)).Error; err != nil {
+			return err
+		}
+		if !drop {
+			return nil
+		}
+		return tx.Exec("DROP TABLE " + quoteIdentifier(partition)).Error
+	})
+}
 
-	err := o.db.WithContext(ctx).
-		Model(&model.OutboxEvent{}).
-		Where(`
-				status = ? 
-			AND 
-				next_retry_at IS NOT NULL 
-			AND 
-				next_retry_at > NOW()
-		`,
-			model.OutboxEventStatusPending,
-		).
-		Count(&count).Error
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
 
-	return count, err
+// PurgeWebhookDeliveries removes one batch of delivery records older than
+// olderThan. They only matter while their event may still be retried.
+func (o *outboxEventService) PurgeWebhookDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	result := o.db.WithContext(ctx).Exec(`
+		DELETE FROM webhook_deliveries
+		WHERE ctid IN (
+			SELECT ctid FROM webhook_deliveries
+			WHERE delivered_at < NOW() - make_interval(secs => ?)
+			LIMIT ?
+		)`,
+		olderThan.Seconds(), limit,
+	)
+
+	return result.RowsAffected, result.Error
 }
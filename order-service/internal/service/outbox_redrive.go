@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (o *outboxEventService) IsRedriven(ctx context.Context, eventID string, failedAt time.Time) (bool, error) {
+	var count int64
+
+	err := o.db.WithContext(ctx).
+		Model(&model.OutboxRedrive{}).
+		Where("event_id = ? AND failed_at = ?", eventID, failedAt).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// RecordRedrive returns false when the DLQ message was already redriven.
+func (o *outboxEventService) RecordRedrive(ctx context.Context, tx *gorm.DB, redrive *model.OutboxRedrive) (bool, error) {
+	result := tx.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(redrive)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// Reinsert puts a dead-lettered event back to pending with a fresh retry
+// budget. The row is re-created when it was purged in the meantime, and left
+// alone when it is already pending, in progress or published again.
+func (o *outboxEventService) Reinsert(ctx context.Context, tx *gorm.DB, event *outboxlib.Event) error {
+	result := tx.WithContext(ctx).
+		Model(&model.OutboxEvent{}).
+		Where("id = ? AND status = ?", event.ID, model.OutboxEventStatusFailed).
+		UpdateColumns(map[string]interface{}{
+			"event_key":      event.EventKey,
+			"status":         model.OutboxEventStatusPending,
+			"retry_count":    0,
+			"next_retry_at":  nil,
+			"locked_at":      nil,
+			"locked_by":      nil,
+			"failure_reason": nil,
+			"failed_at":      nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var exists bool
+		err := tx.WithContext(ctx).
+			Raw("SELECT EXISTS (SELECT 1 FROM outbox_events WHERE id = ?)", event.ID).
+			Scan(&exists).Error
+		if err != nil || exists {
+			return err
+		}
+
+		// Purged rows go to the end of their aggregate.
+		event.Sequence = 0
+		return o.store.Insert(ctx, tx, event)
+	}
+
+	if !o.config.NotifyEnabled {
+		return nil
+	}
+
+	return tx.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", o.config.NotifyChannel, event.ID).Error
+}
+
+func (o *outboxEventService) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return withTransaction(ctx, o.db, fn)
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/config"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/database/model"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/internal/logger"
+	outboxlib "github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/webhookpublisher"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidSubscription = errors.New("invalid webhook subscription")
+
+// WebhookSubscriptionService manages webhook endpoints and is the
+// webhookpublisher.Subscriptions of the webhook transport.
+type WebhookSubscriptionService interface {
+	List(ctx context.Context) ([]*model.WebhookSubscription, error)
+	Get(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	Create(ctx context.Context, input *WebhookSubscriptionInput) (*model.WebhookSubscription, error)
+	Update(ctx context.Context, id string, input *WebhookSubscriptionInput) (*model.WebhookSubscription, error)
+	Delete(ctx context.Context, id string) error
+	Endpoints(ctx context.Context, event *outboxlib.Event) ([]*webhookpublisher.Endpoint, error)
+	Delivered(ctx context.Context, event *outboxlib.Event, endpoint *webhookpublisher.Endpoint) error
+}
+
+// WebhookSubscriptionInput holds the fields to set, nil fields are left alone
+// on Update. A secret is generated when Create gets none.
+type WebhookSubscriptionInput struct {
+	URL         *string
+	Secret      *string
+	EventKeys   []string
+	TimeoutMS   *int
+	RetryPolicy *string
+	Active      *bool
+}
+
+type webhookSubscriptionService struct {
+	db     *gorm.DB
+	log    logger.Logger
+	config *config.Webhook
+}
+
+type WebhookSubscriptionServiceOpts struct {
+	DB     database.DatabaseService
+	Log    logger.Logger
+	Config *config.Webhook
+}
+
+func NewWebhookSubscriptionService(opts *WebhookSubscriptionServiceOpts) WebhookSubscriptionService {
+	return &webhookSubscriptionService{
+		db:     opts.DB.DB(),
+		log:    opts.Log,
+		config: opts.Config,
+	}
+}
+
+func (w *webhookSubscriptionService) List(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	var subscriptions []*model.WebhookSubscription
+	err := w.db.WithContext(ctx).Order("created_at").Find(&subscriptions).Error
+
+	return subscriptions, err
+}
+
+func (w *webhookSubscriptionService) Get(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	if err := w.db.WithContext(ctx).Where("id = ?", id).First(&subscription).Error; err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// Create returns the subscription with its secret, the only time it is shown.
+func (w *webhookSubscriptionService) Create(
+	ctx context.Context,
+	input *WebhookSubscriptionInput,
+) (*model.WebhookSubscription, error) {
+	subscription := &model.WebhookSubscription{ID: uuid.NewString(), Active: true}
+
+	if input.Secret == nil || *input.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		input.Secret = &secret
+	}
+	if input.URL == nil || len(input.EventKeys) == 0 {
+		return nil, fmt.Errorf("%w: url and event_keys are required", ErrInvalidSubscription)
+	}
+	if err := w.apply(subscription, input); err != nil {
+		return nil, err
+	}
+
+	if err := w.db.WithContext(ctx).Create(subscription).Error; err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (w *webhookSubscriptionService) Update(
+	ctx context.Context,
+	id string,
+	input *WebhookSubscriptionInput,
+) (*model.WebhookSubscription, error) {
+	var subscription *model.WebhookSubscription
+
+	err := withTransaction(ctx, w.db, func(tx *gorm.DB) error {
+		subscription = &model.WebhookSubscription{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(subscription).Error
+		if err != nil {
+			return err
+		}
+		if err := w.apply(subscription, input); err != nil {
+			return err
+		}
+
+		return tx.Save(subscription).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (w *webhookSubscriptionService) Delete(ctx context.Context, id string) error {
+	result := w.db.WithContext(ctx).Where("id = ?", id).Delete(&model.WebhookSubscription{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return result.Error
+}
+
+func (w *webhookSubscriptionService) apply(s *model.WebhookSubscription, input *WebhookSubscriptionInput) error {
+	if input.URL != nil {
+		if err := w.validateURL(*input.URL); err != nil {
+			return err
+		}
+		s.URL = *input.URL
+	}
+	if input.Secret != nil {
+		if *input.Secret == "" {
+			return fmt.Errorf("%w: secret must not be empty", ErrInvalidSubscription)
+		}
+		s.Secret = *input.Secret
+	}
+	if input.EventKeys != nil {
+		if len(input.EventKeys) == 0 {
+			return fmt.Errorf("%w: event_keys must not be empty", ErrInvalidSubscription)
+		}
+		s.EventKeys = input.EventKeys
+	}
+	if input.TimeoutMS != nil {
+		if *input.TimeoutMS < 0 {
+			return fmt.Errorf("%w: timeout_ms must not be negative", ErrInvalidSubscription)
+		}
+		s.TimeoutMS = *input.TimeoutMS
+	}
+	if input.RetryPolicy != nil {
+		if *input.RetryPolicy != "" {
+			if _, err := outboxlib.ParseRetryPolicy(*input.RetryPolicy); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+			}
+		}
+		s.RetryPolicy = *input.RetryPolicy
+	}
+	if input.Active != nil {
+		s.Active = *input.Active
+	}
+
+	return nil
+}
+
+func (w *webhookSubscriptionService) validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: url must be absolute", ErrInvalidSubscription)
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && w.config.AllowInsecure) {
+		return fmt.Errorf("%w: url must use https", ErrInvalidSubscription)
+	}
+
+	return nil
+}
+
+// Endpoints returns the active subscriptions matching the event's key that
+// did not receive the event yet.
+func (w *webhookSubscriptionService) Endpoints(
+	ctx context.Context,
+	event *outboxlib.Event,
+) ([]*webhookpublisher.Endpoint, error) {
+	var subscriptions []*model.WebhookSubscription
+
+	err := w.db.WithContext(ctx).
+		Where("active").
+		Where("NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.event_id = ? AND d.subscription_id = webhook_subscriptions.id)", event.ID).
+		Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []*webhookpublisher.Endpoint
+	for _, s := range subscriptions {
+		if !matchesEventKey(s.EventKeys, event.EventKey) {
+			continue
+		}
+
+		endpoint := &webhookpublisher.Endpoint{
+			ID:      s.ID,
+			URL:     s.URL,
+			Secret:  s.Secret,
+			Timeout: time.Duration(s.TimeoutMS) * time.Millisecond,
+		}
+		if s.RetryPolicy != "" {
+			policy, err := outboxlib.ParseRetryPolicy(s.RetryPolicy)
+			if err != nil {
+				w.log.Warn("Invalid webhook retry policy, using the event key's",
+					logger.Field{Key: "subscription_id", Value: s.ID},
+					logger.Field{Key: "error", Value: err.Error()},
+				)
+			} else {
+				endpoint.RetryPolicy = policy
+			}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, nil
+}
+
+func (w *webhookSubscriptionService) Delivered(
+	ctx context.Context,
+	event *outboxlib.Event,
+	endpoint *webhookpublisher.Endpoint,
+) error {
+	return w.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.WebhookDelivery{EventID: event.ID, SubscriptionID: endpoint.ID}).Error
+}
+
+// matchesEventKey matches exact keys and "prefix.*" patterns.
+func matchesEventKey(patterns []string, eventKey string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventKey, prefix) {
+			return true
+		}
+		if pattern == eventKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
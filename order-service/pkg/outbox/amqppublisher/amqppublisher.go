@@ -0,0 +1,120 @@
+// Package amqppublisher is an outbox.Publisher for RabbitMQ. Every session
+// owns a confirm-mode channel, and mandatory event keys are reported as
+// unroutable when no queue is bound for them. Messages carry the payload's
+// content type and its schema reference as type.
+package amqppublisher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/cloudevents"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/compress"
+)
+
+// CloudEventsHeaderPrefix prefixes the attribute headers of binary-mode
+// CloudEvents, as in the AMQP protocol binding.
+const CloudEventsHeaderPrefix = "cloudEvents:"
+
+var (
+	ErrPublishNacked = errors.New("rabbitmq: message nacked by broker")
+	ErrChannelClosed = errors.New("rabbitmq: channel closed")
+	ErrNoDeadLetter  = errors.New("rabbitmq: no dead-letter exchange configured")
+)
+
+// ChannelFunc opens a new channel, e.g. (*amqp091.Connection).Channel.
+type ChannelFunc func() (*amqp091.Channel, error)
+
+type Publisher struct {
+	open                 ChannelFunc
+	exchange             string
+	deadLetterExchange   string
+	deadLetterRoutingKey string
+	mandatory            map[string]bool
+	timeout              time.Duration
+	cloudEvents          *cloudevents.Encoder
+	cloudEventsMode      cloudevents.Mode
+	compressor           *compress.Compressor
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithExchange sets the exchange events are published to, with their event
+// key as routing key. Defaults to the default exchange.
+func WithExchange(name string) Option {
+	return func(p *Publisher) { p.exchange = name }
+}
+
+// WithDeadLetter sets where DeadLetter publishes to.
+func WithDeadLetter(exchange, routingKey string) Option {
+	return func(p *Publisher) {
+		p.deadLetterExchange = exchange
+		p.deadLetterRoutingKey = routingKey
+	}
+}
+
+// WithMandatory publishes the given event keys as mandatory, so a missing
+// binding fails the confirm with outbox.ErrUnroutable.
+func WithMandatory(eventKeys ...string) Option {
+	return func(p *Publisher) {
+		for _, key := range eventKeys {
+			p.mandatory[key] = true
+		}
+	}
+}
+
+// WithPublishTimeout bounds a publish and a confirm each. Defaults to 5s.
+func WithPublishTimeout(d time.Duration) Option {
+	return func(p *Publisher) { p.timeout = d }
+}
+
+// WithCloudEvents publishes events as CloudEvents in the given content mode.
+// Dead letters keep their own envelope.
+func WithCloudEvents(encoder *cloudevents.Encoder, mode cloudevents.Mode) Option {
+	return func(p *Publisher) {
+		p.cloudEvents = encoder
+		p.cloudEventsMode = mode
+	}
+}
+
+// WithCompression compresses event bodies with the compressor and sets the
+// content encoding. Dead letters are left uncompressed.
+func WithCompression(compressor *compress.Compressor) Option {
+	return func(p *Publisher) { p.compressor = compressor }
+}
+
+func New(open ChannelFunc, opts ...Option) *Publisher {
+	p := &Publisher{
+		open:      open,
+		mandatory: map[string]bool{},
+		timeout:   5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// OpenSession opens a confirm-mode channel for the session.
+func (p *Publisher) OpenSession(ctx context.Context) (outbox.Session, error) {
+	ch, err := p.open()
+	if err != nil {
+		return nil, newPublishError("channel", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return nil, newPublishError("channel", err)
+	}
+
+	return &session{
+		publisher: p,
+		ch:        ch,
+		returns:   ch.NotifyReturn(make(chan amqp091.Return, 1)),
+		closed:    ch.NotifyClose(make(chan *amqp091.Error, 1)),
+	}, nil
+}
@@ -0,0 +1,36 @@
+package amqppublisher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+func newPublishError(op string, err error) *outbox.PublishError {
+	class := classify(err)
+
+	switch {
+	case op == "encode":
+		class = outbox.ErrorClassPermanent
+	case errors.Is(err, context.DeadlineExceeded):
+		class = outbox.ErrorClassTransient
+	}
+
+	return &outbox.PublishError{Class: class, Op: op, Err: err}
+}
+
+func classify(err error) outbox.ErrorClass {
+	if errors.Is(err, amqp091.ErrClosed) || errors.Is(err, ErrChannelClosed) {
+		return outbox.ErrorClassConnection
+	}
+
+	// Any protocol exception closes the channel or the connection it was raised on.
+	var amqpErr *amqp091.Error
+	if errors.As(err, &amqpErr) {
+		return outbox.ErrorClassConnection
+	}
+
+	return outbox.ErrorClassTransient
+}
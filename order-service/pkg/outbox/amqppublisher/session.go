@@ -0,0 +1,209 @@
+package amqppublisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/cloudevents"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type session struct {
+	publisher *Publisher
+	ch        *amqp091.Channel
+	returns   <-chan amqp091.Return
+	closed    <-chan *amqp091.Error
+	pending   []pendingConfirm
+}
+
+// pendingConfirm is a publish the broker has not confirmed yet.
+type pendingConfirm struct {
+	confirm    *amqp091.DeferredConfirmation
+	messageID  string
+	routingKey string
+	mandatory  bool
+}
+
+func (s *session) Publish(ctx context.Context, event *outbox.Event) error {
+	msg, err := s.publisher.message(event)
+	if err != nil {
+		return newPublishError("encode", err)
+	}
+
+	mandatory := s.publisher.mandatory[event.EventKey]
+	return s.publish(ctx, s.publisher.exchange, event.EventKey, mandatory, msg)
+}
+
+func (s *session) DeadLetter(ctx context.Context, event *outbox.Event, cause error) error {
+	p := s.publisher
+	if p.deadLetterExchange == "" && p.deadLetterRoutingKey == "" {
+		return &outbox.PublishError{Class: outbox.ErrorClassPermanent, Op: "dead-letter", Err: ErrNoDeadLetter}
+	}
+
+	body, err := json.Marshal(outbox.NewDeadLetterEnvelope(event, cause))
+	if err != nil {
+		return newPublishError("encode", err)
+	}
+
+	msg := amqp091.Publishing{ContentType: outbox.ContentTypeJSON, Body: body, MessageId: event.ID}
+	return s.publish(ctx, p.deadLetterExchange, p.deadLetterRoutingKey, false, msg)
+}
+
+func (s *session) publish(
+	ctx context.Context,
+	exchange string,
+	routingKey string,
+	mandatory bool,
+	msg amqp091.Publishing,
+) error {
+	if s.isClosed() {
+		return newPublishError("publish", ErrChannelClosed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.publisher.timeout)
+	defer cancel()
+
+	msg.Headers = withTraceContext(ctx, msg.Headers)
+	confirm, err := s.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, mandatory, false, msg)
+	if err != nil {
+		return newPublishError("publish", err)
+	}
+
+	s.pending = append(s.pending, pendingConfirm{
+		confirm:    confirm,
+		messageID:  msg.MessageId,
+		routingKey: routingKey,
+		mandatory:  mandatory,
+	})
+	return nil
+}
+
+func (s *session) Confirm(ctx context.Context) error {
+	pending := s.pending
+	s.pending = nil
+
+	ctx, cancel := context.WithTimeout(ctx, s.publisher.timeout)
+	defer cancel()
+
+	for _, p := range pending {
+		acked, err := p.confirm.WaitContext(ctx)
+		if err != nil {
+			return newPublishError("confirm", err)
+		}
+		// pending confirms are nacked when the channel closes
+		if !acked && s.ch.IsClosed() {
+			return newPublishError("confirm", ErrChannelClosed)
+		}
+		if !acked {
+			return newPublishError("confirm", ErrPublishNacked)
+		}
+	}
+
+	// The broker sends basic.return before the basic.ack of the same message,
+	// so every return of the confirmed publishes has arrived by now. Returns are
+	// drained on every confirm so stale ones never block the connection.
+	returned := s.drainReturns()
+	for _, p := range pending {
+		if p.mandatory && returned[p.messageID] {
+			return newPublishError("route", fmt.Errorf("%w: no queue bound for routing key %q", outbox.ErrUnroutable, p.routingKey))
+		}
+	}
+
+	return nil
+}
+
+func (s *session) Close() error {
+	if s.ch.IsClosed() {
+		return nil
+	}
+	return s.ch.Close()
+}
+
+func (s *session) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *session) drainReturns() map[string]bool {
+	returned := map[string]bool{}
+	for {
+		select {
+		case ret := <-s.returns:
+			returned[ret.MessageId] = true
+		default:
+			return returned
+		}
+	}
+}
+
+// message encodes an event as its payload or as a CloudEvent, compressed when
+// configured.
+func (p *Publisher) message(event *outbox.Event) (amqp091.Publishing, error) {
+	msg := amqp091.Publishing{
+		ContentType: event.PayloadContentType(),
+		Type:        event.SchemaRef,
+		Body:        event.Payload,
+		MessageId:   event.ID,
+	}
+	if p.cloudEvents != nil {
+		if err := p.encodeCloudEvent(&msg, event); err != nil {
+			return msg, err
+		}
+	}
+	if p.compressor != nil {
+		body, encoding, err := p.compressor.Compress(msg.Body)
+		if err != nil {
+			return msg, err
+		}
+		msg.Body = body
+		msg.ContentEncoding = encoding
+	}
+
+	return msg, nil
+}
+
+func (p *Publisher) encodeCloudEvent(msg *amqp091.Publishing, event *outbox.Event) error {
+	ce := p.cloudEvents.Event(event)
+	msg.Timestamp = ce.Time
+	msg.Type = ce.Type
+
+	if p.cloudEventsMode == cloudevents.ModeStructured {
+		body, err := ce.MarshalStructured()
+		if err != nil {
+			return err
+		}
+		msg.ContentType = cloudevents.ContentTypeStructured
+		msg.Body = body
+		return nil
+	}
+
+	msg.ContentType = ce.DataContentType
+	msg.Headers = amqp091.Table{}
+	for name, value := range ce.Attributes() {
+		msg.Headers[CloudEventsHeaderPrefix+name] = value
+	}
+
+	return nil
+}
+
+func withTraceContext(ctx context.Context, headers amqp091.Table) amqp091.Table {
+	if headers == nil {
+		headers = amqp091.Table{}
+	}
+	carrier := propagation.MapCarrier{}
+
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	for k, v := range carrier {
+		headers[k] = v
+	}
+
+	return headers
+}
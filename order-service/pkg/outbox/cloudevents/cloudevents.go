@@ -0,0 +1,183 @@
+// Package cloudevents maps outbox events to CloudEvents 1.0 and encodes them
+// for the binary and structured content modes of the protocol bindings.
+//
+// Attributes are derived from the outbox event:
+//
+//	id          event ID
+//	source      configured per service
+//	type        type prefix + event key
+//	time        event creation time
+//	subject     aggregate ID, when set
+//	dataschema  from the configured function, when set
+//	schemaref   extension, the event's schema reference, when set
+//
+// datacontenttype is the event's payload content type. dataschema must be a
+// URI, which a schema reference is not, so it is only set from a configured
+// function, e.g. a URL for humans and tools. Consumers decode by schemaref and
+// only fall back to dataschema when it is missing.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	// ContentTypeStructured is the content type of a structured-mode message.
+	ContentTypeStructured = "application/cloudevents+json"
+)
+
+// Mode is a content mode of a protocol binding.
+type Mode string
+
+const (
+	ModeBinary     Mode = "binary"     // attributes in headers, payload as body
+	ModeStructured Mode = "structured" // attributes and payload in a JSON body
+)
+
+// ParseMode accepts "binary" and "structured".
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case ModeBinary, ModeStructured:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("cloudevents: unknown content mode %q", s)
+	}
+}
+
+// Event is a CloudEvent with the attributes this package sets.
+type Event struct {
+	SpecVersion     string
+	ID              string
+	Source          string
+	Type            string
+	Time            time.Time
+	Subject         string
+	DataSchema      string
+	SchemaRef       string // schemaref extension attribute
+	DataContentType string
+	Data            []byte
+}
+
+// Encoder derives CloudEvents from outbox events.
+type Encoder struct {
+	source     string
+	typePrefix string
+	dataSchema func(*outbox.Event) string
+}
+
+// Option configures an Encoder.
+type Option func(*Encoder)
+
+// WithTypePrefix is prepended to the event key to form the type, e.g.
+// "com.example.orders." for "order.created".
+func WithTypePrefix(prefix string) Option {
+	return func(e *Encoder) { e.typePrefix = prefix }
+}
+
+// WithDataSchema sets the dataschema of an event, which must be a URI. An
+// empty result leaves the attribute out.
+func WithDataSchema(fn func(*outbox.Event) string) Option {
+	return func(e *Encoder) { e.dataSchema = fn }
+}
+
+// NewEncoder returns an Encoder for events produced by source, a URI-reference
+// such as "/order-service".
+func NewEncoder(source string, opts ...Option) *Encoder {
+	e := &Encoder{source: source}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+func (e *Encoder) Event(event *outbox.Event) *Event {
+	ce := &Event{
+		SpecVersion:     SpecVersion,
+		ID:              event.ID,
+		Source:          e.source,
+		Type:            e.typePrefix + event.EventKey,
+		Time:            event.CreatedAt,
+		Subject:         event.AggregateID,
+		SchemaRef:       event.SchemaRef,
+		DataContentType: event.PayloadContentType(),
+		Data:            event.Payload,
+	}
+	if e.dataSchema != nil {
+		ce.DataSchema = e.dataSchema(event)
+	}
+
+	return ce
+}
+
+// Attributes returns the context attributes of binary mode by name, optional
+// attributes that are not set are left out. datacontenttype is not included,
+// bindings carry it as the message's content type.
+func (ce *Event) Attributes() map[string]string {
+	attrs := map[string]string{
+		"specversion": ce.SpecVersion,
+		"id":          ce.ID,
+		"source":      ce.Source,
+		"type":        ce.Type,
+	}
+	if !ce.Time.IsZero() {
+		attrs["time"] = ce.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if ce.Subject != "" {
+		attrs["subject"] = ce.Subject
+	}
+	if ce.DataSchema != "" {
+		attrs["dataschema"] = ce.DataSchema
+	}
+	if ce.SchemaRef != "" {
+		attrs["schemaref"] = ce.SchemaRef
+	}
+
+	return attrs
+}
+
+// structured is the JSON event format. JSON data is embedded as is, anything
+// else goes into data_base64.
+type structured struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time,omitempty"`
+	Subject         string          `json:"subject,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	SchemaRef       string          `json:"schemaref,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// MarshalStructured encodes the event in the JSON event format.
+func (ce *Event) MarshalStructured() ([]byte, error) {
+	attrs := ce.Attributes()
+	s := structured{
+		SpecVersion:     ce.SpecVersion,
+		ID:              ce.ID,
+		Source:          ce.Source,
+		Type:            ce.Type,
+		Time:            attrs["time"],
+		Subject:         ce.Subject,
+		DataSchema:      ce.DataSchema,
+		SchemaRef:       ce.SchemaRef,
+		DataContentType: ce.DataContentType,
+	}
+	if outbox.IsJSON(ce.DataContentType) && json.Valid(ce.Data) {
+		s.Data = ce.Data
+	} else {
+		s.DataBase64 = ce.Data
+	}
+
+	return json.Marshal(s)
+}
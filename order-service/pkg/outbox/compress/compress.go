@@ -0,0 +1,97 @@
+// Package compress compresses message bodies above a size threshold for
+// publishers that can flag a content encoding, e.g. AMQP's content-encoding.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// Result describes one compressed body, for metrics.
+type Result struct {
+	Encoding       string
+	OriginalSize   int
+	CompressedSize int
+}
+
+type Compressor struct {
+	encoding  string
+	threshold int
+	zstd      *zstd.Encoder
+	observe   func(*Result)
+}
+
+// Option configures a Compressor.
+type Option func(*Compressor)
+
+// WithObserver is called for every compressed body.
+func WithObserver(observe func(*Result)) Option {
+	return func(c *Compressor) { c.observe = observe }
+}
+
+// New compresses bodies of at least threshold bytes with encoding, gzip or zstd.
+func New(encoding string, threshold int, opts ...Option) (*Compressor, error) {
+	c := &Compressor{encoding: encoding, threshold: threshold}
+
+	switch encoding {
+	case EncodingGzip:
+	case EncodingZstd:
+		// EncodeAll is safe for concurrent use.
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		c.zstd = enc
+	default:
+		return nil, fmt.Errorf("compress: unknown encoding %q", encoding)
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Compress returns the body to send and its content encoding. Bodies below the
+// threshold, and bodies that would not get smaller, are returned unchanged
+// with an empty encoding.
+func (c *Compressor) Compress(body []byte) ([]byte, string, error) {
+	if len(body) < c.threshold {
+		return body, "", nil
+	}
+
+	var compressed []byte
+	switch c.encoding {
+	case EncodingGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, "", err
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", err
+		}
+		compressed = buf.Bytes()
+	case EncodingZstd:
+		compressed = c.zstd.EncodeAll(body, make([]byte, 0, len(body)/2))
+	}
+
+	if len(compressed) >= len(body) {
+		return body, "", nil
+	}
+
+	if c.observe != nil {
+		c.observe(&Result{Encoding: c.encoding, OriginalSize: len(body), CompressedSize: len(compressed)})
+	}
+
+	return compressed, c.encoding, nil
+}
@@ -0,0 +1,26 @@
+// Package outbox relays events that were written to an outbox table, in the
+// same transaction as the business data, to a message broker.
+//
+// A Relay claims due events from a Store, publishes them through a Publisher
+// and records the outcome. Events of one aggregate are published in insert
+// order by a single worker. Failed publishes are retried according to a
+// RetryPolicy and dead-lettered once it is exhausted. Claims are leases that
+// are renewed while an event is in flight, so events of a crashed relay are
+// picked up by another one.
+//
+// A Publisher is transport-agnostic: every worker opens its own Session,
+// publishes through it and waits for the broker's confirm. The gormstore and
+// amqppublisher packages provide a Postgres Store and a RabbitMQ Publisher,
+// memorybroker an in-process Publisher for development and tests:
+//
+//	store := gormstore.New(db, gormstore.WithNotify("outbox_events"))
+//	publisher := amqppublisher.New(conn.Channel, amqppublisher.WithExchange("events"))
+//	relay, err := outbox.New(store, publisher, outbox.WithConcurrency(4))
+//
+//	go relay.Run(ctx)
+//	defer relay.Stop(shutdownCtx)
+//
+// Producers add events with Store.Insert inside their own transaction.
+// Payloads can be encrypted at rest with a Cipher, e.g. the encryption
+// package, which the relay uses to decrypt them right before publishing.
+package outbox
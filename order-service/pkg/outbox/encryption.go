@@ -0,0 +1,71 @@
+package outbox
+
+import "errors"
+
+// ErrNoCipher is returned for encrypted events when no Cipher is configured.
+var ErrNoCipher = errors.New("outbox: event is encrypted but no cipher is configured")
+
+// Encryption scopes.
+const (
+	// EncryptionScopePayload encrypts the payload as a whole.
+	EncryptionScopePayload = "payload"
+	// EncryptionScopeFields encrypts selected fields of a JSON object payload
+	// and leaves the rest readable.
+	EncryptionScopeFields = "fields"
+)
+
+// Encryption describes how a payload was encrypted at rest. Payloads are
+// encrypted with a data key of their own, which is stored wrapped by a key
+// of the keyring.
+type Encryption struct {
+	KeyID      string // keyring key WrappedKey is encrypted with
+	WrappedKey []byte // nil once the data key was shredded
+	Scope      string // EncryptionScopePayload | EncryptionScopeFields
+}
+
+// Cipher encrypts payloads at rest. Stores encrypt events on Insert and the
+// relay decrypts them right before they are published, so the outbox table
+// never holds the plaintext.
+type Cipher interface {
+	// Encrypt returns the payload to store and how it was encrypted, or the
+	// payload unchanged and nil for events that are not to be encrypted.
+	Encrypt(event *Event) ([]byte, *Encryption, error)
+	// Decrypt returns the plaintext payload of an event with Encryption set.
+	// Failures that retrying cannot fix, e.g. a shredded data key or a payload
+	// that fails authentication, are returned as a permanent *PublishError.
+	Decrypt(event *Event) ([]byte, error)
+}
+
+// DecryptEvent returns events stored in plaintext as they are and a plaintext
+// copy of encrypted ones. An encrypted event without a cipher to decrypt it
+// is a permanent failure.
+func DecryptEvent(c Cipher, event *Event) (*Event, error) {
+	if event.Encryption == nil {
+		return event, nil
+	}
+	if c == nil {
+		return event, &PublishError{Class: ErrorClassPermanent, Op: "decrypt", Err: ErrNoCipher}
+	}
+
+	return event.Decrypt(c)
+}
+
+// Decrypt returns a copy of the event with its plaintext payload. Failures
+// the cipher did not report as a *PublishError are transient, e.g. a key
+// that is not in the local keyring yet.
+func (e *Event) Decrypt(c Cipher) (*Event, error) {
+	payload, err := c.Decrypt(e)
+	if err != nil {
+		var publishErr *PublishError
+		if !errors.As(err, &publishErr) {
+			err = &PublishError{Class: ErrorClassTransient, Op: "decrypt", Err: err}
+		}
+		return e, err
+	}
+
+	plain := *e
+	plain.Payload = payload
+	plain.Encryption = nil
+
+	return &plain, nil
+}
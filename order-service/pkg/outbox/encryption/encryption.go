@@ -0,0 +1,259 @@
+// Package encryption is an outbox.Cipher that envelope-encrypts payloads at
+// rest with AES-256-GCM. Every event gets a random data key, which is stored
+// next to the payload wrapped with the primary key of a Keyring.
+//
+// Keys are rotated by adding a new primary key and rewrapping the stored data
+// keys, the payloads themselves are never re-encrypted. Destroying a key
+// crypto-shreds every payload whose data key is wrapped with it.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+// AllFields encrypts the whole payload instead of selected fields.
+const AllFields = "*"
+
+// encryptedField replaces the value of an encrypted field in a JSON payload.
+const encryptedField = "$encrypted"
+
+var ErrShredded = errors.New("encryption: data key was shredded")
+
+type Cipher struct {
+	keyring *Keyring
+	fields  map[string][]string
+}
+
+// Option configures a Cipher.
+type Option func(*Cipher)
+
+// WithFields sets which payloads are encrypted, by event key, or "*" for
+// every other event key. Only the listed top-level fields of a JSON object
+// payload are encrypted, AllFields encrypts the whole payload. Events without
+// an entry are stored in plaintext.
+func WithFields(fields map[string][]string) Option {
+	return func(c *Cipher) { c.fields = fields }
+}
+
+func New(keyring *Keyring, opts ...Option) *Cipher {
+	c := &Cipher{keyring: keyring}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Cipher) Keyring() *Keyring {
+	return c.keyring
+}
+
+func (c *Cipher) fieldsFor(eventKey string) ([]string, bool) {
+	if fields, ok := c.fields[eventKey]; ok {
+		return fields, true
+	}
+	fields, ok := c.fields["*"]
+	return fields, ok
+}
+
+func (c *Cipher) Encrypt(event *outbox.Event) ([]byte, *outbox.Encryption, error) {
+	fields, ok := c.fieldsFor(event.EventKey)
+	if !ok {
+		return event.Payload, nil, nil
+	}
+
+	dataKey, err := newKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryption := &outbox.Encryption{Scope: outbox.EncryptionScopeFields}
+	var payload []byte
+	if len(fields) == 0 || fields[0] == AllFields {
+		encryption.Scope = outbox.EncryptionScopePayload
+		payload, err = seal(dataKey, event.Payload, []byte(event.ID))
+	} else {
+		payload, err = encryptFields(dataKey, event, fields)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyID, key := c.keyring.primaryKey()
+	wrapped, err := seal(key, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, nil, err
+	}
+	encryption.KeyID, encryption.WrappedKey = keyID, wrapped
+
+	return payload, encryption, nil
+}
+
+// Decrypt reloads the keyring once when the event's key is not in it, e.g.
+// after another process rotated it, and fails transiently when it is still
+// missing. Shredded data keys and payloads that fail authentication are
+// permanent failures.
+func (c *Cipher) Decrypt(event *outbox.Event) ([]byte, error) {
+	dataKey, err := c.unwrap(event.Encryption)
+	if errors.Is(err, ErrKeyNotFound) {
+		if err := c.keyring.Reload(); err != nil {
+			return nil, fmt.Errorf("encryption: reload keyring: %w", err)
+		}
+		dataKey, err = c.unwrap(event.Encryption)
+	}
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, permanent(err)
+	}
+
+	var payload []byte
+	switch event.Encryption.Scope {
+	case outbox.EncryptionScopePayload:
+		payload, err = open(dataKey, event.Payload, []byte(event.ID))
+	case outbox.EncryptionScopeFields:
+		payload, err = decryptFields(dataKey, event)
+	default:
+		err = fmt.Errorf("encryption: unknown scope %q", event.Encryption.Scope)
+	}
+	if err != nil {
+		return nil, permanent(err)
+	}
+
+	return payload, nil
+}
+
+func permanent(err error) error {
+	return &outbox.PublishError{Class: outbox.ErrorClassPermanent, Op: "decrypt", Err: err}
+}
+
+// Rewrap returns encryption with its data key wrapped by the primary key.
+// The payload stays as it is.
+func (c *Cipher) Rewrap(encryption *outbox.Encryption) (*outbox.Encryption, error) {
+	dataKey, err := c.unwrap(encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, key := c.keyring.primaryKey()
+	wrapped, err := seal(key, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &outbox.Encryption{KeyID: keyID, WrappedKey: wrapped, Scope: encryption.Scope}, nil
+}
+
+func (c *Cipher) unwrap(encryption *outbox.Encryption) ([]byte, error) {
+	if encryption.WrappedKey == nil {
+		return nil, ErrShredded
+	}
+
+	key, err := c.keyring.key(encryption.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(key, encryption.WrappedKey, []byte(encryption.KeyID))
+}
+
+// encryptFields replaces the listed fields with {"$encrypted": "<base64>"},
+// bound to the event and field name so they cannot be swapped around.
+func encryptFields(dataKey []byte, event *outbox.Event, fields []string) ([]byte, error) {
+	if !outbox.IsJSON(event.PayloadContentType()) {
+		return nil, fmt.Errorf("encryption: cannot encrypt fields of a %s payload", event.PayloadContentType())
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(event.Payload, &object); err != nil {
+		return nil, fmt.Errorf("encryption: payload is not a JSON object: %w", err)
+	}
+
+	for _, field := range fields {
+		value, ok := object[field]
+		if !ok {
+			continue
+		}
+
+		sealed, err := seal(dataKey, value, fieldData(event.ID, field))
+		if err != nil {
+			return nil, err
+		}
+		if object[field], err = json.Marshal(map[string][]byte{encryptedField: sealed}); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(object)
+}
+
+func decryptFields(dataKey []byte, event *outbox.Event) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(event.Payload, &object); err != nil {
+		return nil, fmt.Errorf("encryption: payload is not a JSON object: %w", err)
+	}
+
+	for field, value := range object {
+		var wrapper map[string][]byte
+		if json.Unmarshal(value, &wrapper) != nil || len(wrapper) != 1 || wrapper[encryptedField] == nil {
+			continue
+		}
+
+		plain, err := open(dataKey, wrapper[encryptedField], fieldData(event.ID, field))
+		if err != nil {
+			return nil, fmt.Errorf("encryption: field %q: %w", field, err)
+		}
+		object[field] = plain
+	}
+
+	return json.Marshal(object)
+}
+
+func fieldData(eventID, field string) []byte {
+	return []byte(eventID + "/" + field)
+}
+
+// seal encrypts plaintext with AES-GCM and prepends the random nonce.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("encryption: ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,109 @@
+package encryption
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+func newTestCipher(t *testing.T) (*Cipher, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	if err := NewKeyringFile(path, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(keyring, WithFields(map[string][]string{AllFields: {AllFields}})), path
+}
+
+func encrypt(t *testing.T, c *Cipher) *outbox.Event {
+	t.Helper()
+
+	event := &outbox.Event{ID: "e1", EventKey: "order.created", Payload: []byte(`{"id":1}`)}
+	payload, encryption, err := c.Encrypt(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.Payload, event.Encryption = payload, encryption
+	return event
+}
+
+func TestDecryptReloadsKeyringForUnknownKey(t *testing.T) {
+	relay, path := newTestCipher(t)
+
+	// the producer rotated the keyring after the relay loaded it
+	producerKeyring, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := producerKeyring.Rotate("k2"); err != nil {
+		t.Fatal(err)
+	}
+	event := encrypt(t, New(producerKeyring, WithFields(map[string][]string{AllFields: {AllFields}})))
+
+	plain, err := event.Decrypt(relay)
+	if err != nil {
+		t.Fatalf("Decrypt = %v, want the keyring reloaded", err)
+	}
+	if string(plain.Payload) != `{"id":1}` {
+		t.Errorf("payload = %s", plain.Payload)
+	}
+}
+
+func TestDecryptErrorClasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(event *outbox.Event)
+		class  outbox.ErrorClass
+		is     error
+	}{
+		{
+			name:   "key missing from the keyring",
+			tamper: func(event *outbox.Event) { event.Encryption.KeyID = "unknown" },
+			class:  outbox.ErrorClassTransient,
+			is:     ErrKeyNotFound,
+		},
+		{
+			name:   "shredded data key",
+			tamper: func(event *outbox.Event) { event.Encryption.WrappedKey = nil },
+			class:  outbox.ErrorClassPermanent,
+			is:     ErrShredded,
+		},
+		{
+			name:   "tampered payload",
+			tamper: func(event *outbox.Event) { event.Payload[len(event.Payload)-1] ^= 1 },
+			class:  outbox.ErrorClassPermanent,
+		},
+		{
+			name:   "payload of another event",
+			tamper: func(event *outbox.Event) { event.ID = "e2" },
+			class:  outbox.ErrorClassPermanent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestCipher(t)
+			event := encrypt(t, c)
+			tt.tamper(event)
+
+			_, err := event.Decrypt(c)
+			if err == nil {
+				t.Fatal("Decrypt succeeded")
+			}
+			if class := outbox.Classify(err); class != tt.class {
+				t.Errorf("class = %s, want %s: %v", class, tt.class, err)
+			}
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Errorf("Decrypt = %v, want %v", err, tt.is)
+			}
+		})
+	}
+}
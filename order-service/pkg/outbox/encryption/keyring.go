@@ -0,0 +1,311 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// KeySize is the size of keyring and data keys, for AES-256.
+const KeySize = 32
+
+var ErrKeyNotFound = errors.New("encryption: key not found in keyring")
+
+// keyringFile is the format of a keyring file:
+//
+//	{"primary": "2026-10", "keys": {"2026-09": "<base64>", "2026-10": "<base64>"}}
+//
+// Keys listed in "destroying" are being destroyed, their data keys are shredded
+// and must not be rewrapped in the meantime.
+type keyringFile struct {
+	Primary    string            `json:"primary"`
+	Keys       map[string][]byte `json:"keys"`
+	Destroying []string          `json:"destroying,omitempty"`
+}
+
+// Keyring holds the keys that wrap data keys, loaded from a local file. New
+// data keys are wrapped with the primary key, older keys stay until every
+// data key wrapped with them was rewrapped.
+type Keyring struct {
+	path string
+
+	mu         sync.RWMutex
+	primary    string
+	keys       map[string][]byte
+	destroying []string
+}
+
+func LoadKeyring(path string) (*Keyring, error) {
+	k := &Keyring{path: path}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Reload rereads the keyring file, e.g. after a key was rotated or destroyed
+// by another process.
+func (k *Keyring) Reload() error {
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return err
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("encryption: invalid keyring %s: %w", k.path, err)
+	}
+	if err := file.validate(); err != nil {
+		return fmt.Errorf("encryption: invalid keyring %s: %w", k.path, err)
+	}
+
+	k.mu.Lock()
+	k.primary, k.keys, k.destroying = file.Primary, file.Keys, file.Destroying
+	k.mu.Unlock()
+
+	return nil
+}
+
+func (f *keyringFile) validate() error {
+	for id, key := range f.Keys {
+		if id == "" {
+			return errors.New("empty key ID")
+		}
+		if len(key) != KeySize {
+			return fmt.Errorf("key %q is %d bytes, want %d", id, len(key), KeySize)
+		}
+	}
+	if _, ok := f.Keys[f.Primary]; !ok {
+		return fmt.Errorf("primary key %q not found", f.Primary)
+	}
+	for _, id := range f.Destroying {
+		if _, ok := f.Keys[id]; !ok || id == f.Primary {
+			return fmt.Errorf("key %q being destroyed is missing or the primary", id)
+		}
+	}
+
+	return nil
+}
+
+// Primary returns the ID of the key new data keys are wrapped with.
+func (k *Keyring) Primary() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.primary
+}
+
+// IDs returns the IDs of all keys, sorted.
+func (k *Keyring) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Destroying reports whether the key is being destroyed.
+func (k *Keyring) Destroying(id string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return slices.Contains(k.destroying, id)
+}
+
+// Stale returns the IDs of the keys whose data keys are to be rewrapped with
+// the primary key, all but the primary and keys being destroyed.
+func (k *Keyring) Stale() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var stale []string
+	for id := range k.keys {
+		if id != k.primary && !slices.Contains(k.destroying, id) {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+
+	return stale
+}
+
+func (k *Keyring) key(id string) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, id)
+	}
+
+	return key, nil
+}
+
+func (k *Keyring) primaryKey() (string, []byte) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.primary, k.keys[k.primary]
+}
+
+// Rotate adds a new random key under id, makes it the primary and saves the
+// keyring file. Data keys wrapped with older keys stay readable until they
+// are rewrapped.
+func (k *Keyring) Rotate(id string) error {
+	key, err := newKey()
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.keys[id]; ok || id == "" {
+		return fmt.Errorf("encryption: key ID %q is empty or taken", id)
+	}
+
+	keys := make(map[string][]byte, len(k.keys)+1)
+	for existing, v := range k.keys {
+		keys[existing] = v
+	}
+	keys[id] = key
+
+	if err := k.save(&keyringFile{Primary: id, Keys: keys, Destroying: k.destroying}); err != nil {
+		return err
+	}
+	k.primary, k.keys = id, keys
+
+	return nil
+}
+
+// MarkDestroying saves the key as being destroyed, so relays stop rewrapping
+// its data keys before they are shredded. The primary key cannot be destroyed.
+func (k *Keyring) MarkDestroying(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.destroyable(id); err != nil {
+		return err
+	}
+	if slices.Contains(k.destroying, id) {
+		return nil
+	}
+
+	destroying := append(slices.Clone(k.destroying), id)
+	if err := k.save(&keyringFile{Primary: k.primary, Keys: k.keys, Destroying: destroying}); err != nil {
+		return err
+	}
+	k.destroying = destroying
+
+	return nil
+}
+
+// Destroy removes a key from the keyring file. Every payload whose data key
+// is wrapped with it becomes unreadable, including archived ones, which is
+// how payloads are crypto-shredded. The primary key cannot be destroyed.
+func (k *Keyring) Destroy(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.destroyable(id); err != nil {
+		return err
+	}
+
+	keys := make(map[string][]byte, len(k.keys)-1)
+	for existing, v := range k.keys {
+		if existing != id {
+			keys[existing] = v
+		}
+	}
+	destroying := slices.DeleteFunc(slices.Clone(k.destroying), func(d string) bool { return d == id })
+
+	if err := k.save(&keyringFile{Primary: k.primary, Keys: keys, Destroying: destroying}); err != nil {
+		return err
+	}
+	k.keys, k.destroying = keys, destroying
+
+	return nil
+}
+
+func (k *Keyring) destroyable(id string) error {
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w: %q", ErrKeyNotFound, id)
+	}
+	if id == k.primary {
+		return fmt.Errorf("encryption: cannot destroy the primary key %q, rotate first", id)
+	}
+
+	return nil
+}
+
+// save replaces the keyring file atomically, so a crash never leaves it
+// half written.
+func (k *Keyring) save(file *keyringFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".keyring-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), k.path)
+}
+
+// NewKeyringFile writes a keyring file with a single random primary key.
+func NewKeyringFile(path, id string) error {
+	if id == "" {
+		return errors.New("encryption: empty key ID")
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("encryption: %s already exists", path)
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return err
+	}
+
+	k := &Keyring{path: path}
+	return k.save(&keyringFile{Primary: id, Keys: map[string][]byte{id: key}})
+}
+
+func newKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
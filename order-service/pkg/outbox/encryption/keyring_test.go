@@ -0,0 +1,57 @@
+package encryption
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestKeyringDestroyingKeysAreNotStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	if err := NewKeyringFile(path, "k1"); err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"k2", "k3"} {
+		if err := keyring.Rotate(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := keyring.MarkDestroying("k3"); err == nil {
+		t.Fatal("MarkDestroying of the primary key succeeded")
+	}
+	if err := keyring.MarkDestroying("k1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// another process, e.g. a relay, sees the marker after reloading
+	relay, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !relay.Destroying("k1") {
+		t.Error("k1 not marked as destroying after reload")
+	}
+	if stale := relay.Stale(); !slices.Equal(stale, []string{"k2"}) {
+		t.Errorf("Stale = %v, want [k2]", stale)
+	}
+
+	if err := keyring.Destroy("k1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := relay.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if relay.Destroying("k1") || slices.Contains(relay.IDs(), "k1") {
+		t.Errorf("k1 still in the keyring after Destroy: %v", relay.IDs())
+	}
+	if _, err := relay.key("k1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("key(k1) = %v, want ErrKeyNotFound", err)
+	}
+}
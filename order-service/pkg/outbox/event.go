@@ -0,0 +1,67 @@
+package outbox
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusPublished  = "published"
+	StatusFailed     = "failed"
+)
+
+// Content types of common payload encodings.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+// Event is a message waiting in the outbox.
+type Event struct {
+	ID          string
+	EventKey    string      // routing key the event is published under
+	AggregateID string      // events of one aggregate are published in insert order
+	Sequence    int64       // position within the aggregate, assigned by the store
+	Payload     []byte      // opaque bytes, encoded as ContentType
+	ContentType string      // media type of Payload, empty means JSON
+	SchemaRef   string      // schema the payload was encoded with, e.g. a message name or schema URI
+	Traceparent string      // W3C traceparent of the request that produced the event
+	Encryption  *Encryption // set while Payload is encrypted at rest
+	RetryCount  int
+	LockedBy    string // owner of the current claim
+	CreatedAt   time.Time
+}
+
+// PayloadContentType returns the content type of the payload, defaulting to JSON.
+func (e *Event) PayloadContentType() string {
+	if e.ContentType == "" {
+		return ContentTypeJSON
+	}
+	return e.ContentType
+}
+
+// IsJSON reports whether contentType is application/json or a +json type.
+func IsJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// StateUpdate moves a claimed event to its next state and releases the claim.
+type StateUpdate struct {
+	Status        string
+	RetryCount    int       // StatusPending only
+	NextRetryAt   time.Time // StatusPending only
+	FailureReason string    // StatusFailed only
+	FailedAt      time.Time // StatusFailed only
+}
+
+// Counts is a snapshot of the outbox used for backlog reporting.
+type Counts struct {
+	Backlog      int64 // events that are due now, including expired claims
+	WaitingRetry int64 // pending events with a retry scheduled in the future
+}
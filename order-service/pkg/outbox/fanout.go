@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+)
+
+// DeliveryTracker records which destinations of a Fanout confirmed an event,
+// so a retry caused by one destination skips the others.
+type DeliveryTracker interface {
+	// Delivered returns the names of the destinations that confirmed the event.
+	Delivered(ctx context.Context, event *Event) ([]string, error)
+	// MarkDelivered records that destination confirmed the event.
+	MarkDelivered(ctx context.Context, event *Event, destination string) error
+}
+
+// Destination is one publisher of a Fanout. Its name identifies it in the
+// DeliveryTracker and must stay the same across restarts.
+type Destination struct {
+	Name      string
+	Publisher Publisher
+}
+
+// Fanout is a Publisher that publishes every event through all of its
+// destinations, e.g. to a broker and to webhooks. An event counts as published
+// once all of them confirmed it. When one fails, the event is retried only on
+// the destinations the tracker has no delivery for.
+type Fanout struct {
+	destinations []Destination
+	tracker      DeliveryTracker
+}
+
+// NewFanout dead-letters through the first destination. Without a tracker an
+// event is retried on all destinations, so consumers must dedupe by event ID.
+func NewFanout(tracker DeliveryTracker, destinations ...Destination) *Fanout {
+	return &Fanout{destinations: destinations, tracker: tracker}
+}
+
+func (f *Fanout) OpenSession(ctx context.Context) (Session, error) {
+	s := &fanoutSession{fanout: f, pending: make([][]*Event, len(f.destinations))}
+	for _, destination := range f.destinations {
+		session, err := destination.Publisher.OpenSession(ctx)
+		if err != nil {
+			_ = s.Close()
+			return nil, err
+		}
+		s.sessions = append(s.sessions, session)
+	}
+
+	return s, nil
+}
+
+type fanoutSession struct {
+	fanout   *Fanout
+	sessions []Session
+	pending  [][]*Event // per destination, events published since the last confirm
+}
+
+func (s *fanoutSession) Publish(ctx context.Context, event *Event) error {
+	delivered, err := s.delivered(ctx, event)
+	if err != nil {
+		return &PublishError{Class: ErrorClassTransient, Op: "deliveries", Err: err}
+	}
+
+	for i, session := range s.sessions {
+		if delivered[s.fanout.destinations[i].Name] {
+			continue
+		}
+		if err := session.Publish(ctx, event); err != nil {
+			// Confirm what the other destinations already accepted, so the
+			// retry skips them.
+			_ = s.Confirm(ctx)
+			return err
+		}
+		s.pending[i] = append(s.pending[i], event)
+	}
+
+	return nil
+}
+
+func (s *fanoutSession) DeadLetter(ctx context.Context, event *Event, cause error) error {
+	return s.sessions[0].DeadLetter(ctx, event, cause)
+}
+
+// Confirm waits for every destination, also after one of them failed, and
+// records the deliveries of those that confirmed.
+func (s *fanoutSession) Confirm(ctx context.Context) error {
+	var failure error
+	for i, session := range s.sessions {
+		events := s.pending[i]
+		s.pending[i] = nil
+
+		err := session.Confirm(ctx)
+		if err == nil {
+			err = s.markDelivered(ctx, s.fanout.destinations[i].Name, events)
+		}
+		if err != nil && failure == nil {
+			failure = err
+		}
+	}
+
+	return failure
+}
+
+func (s *fanoutSession) Close() error {
+	var errs []error
+	for i, session := range s.sessions {
+		s.pending[i] = nil
+		errs = append(errs, session.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *fanoutSession) delivered(ctx context.Context, event *Event) (map[string]bool, error) {
+	if s.fanout.tracker == nil {
+		return nil, nil
+	}
+
+	names, err := s.fanout.tracker.Delivered(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivered := make(map[string]bool, len(names))
+	for _, name := range names {
+		delivered[name] = true
+	}
+	return delivered, nil
+}
+
+func (s *fanoutSession) markDelivered(ctx context.Context, destination string, events []*Event) error {
+	if s.fanout.tracker == nil {
+		return nil
+	}
+
+	for _, event := range events {
+		if err := s.fanout.tracker.MarkDelivered(ctx, event, destination); err != nil {
+			return &PublishError{Class: ErrorClassTransient, Op: "deliveries", Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/memorybroker"
+)
+
+type memTracker struct {
+	mu        sync.Mutex
+	delivered map[string][]string
+}
+
+func (t *memTracker) Delivered(ctx context.Context, event *outbox.Event) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.delivered[event.ID], nil
+}
+
+func (t *memTracker) MarkDelivered(ctx context.Context, event *outbox.Event, destination string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.delivered[event.ID] = append(t.delivered[event.ID], destination)
+	return nil
+}
+
+func TestFanoutRetriesOnlyFailedDestinations(t *testing.T) {
+	ctx := context.Background()
+
+	broker := memorybroker.New()
+	brokerSub := broker.Subscribe("#", 10)
+	// mandatory without a subscription fails until one is added
+	webhooks := memorybroker.New(memorybroker.WithMandatory("order.created"))
+
+	tracker := &memTracker{delivered: map[string][]string{}}
+	fanout := outbox.NewFanout(tracker,
+		outbox.Destination{Name: "amqp", Publisher: broker},
+		outbox.Destination{Name: "webhook", Publisher: webhooks},
+	)
+	session := outbox.NewSessionHolder(fanout)
+	defer session.Close()
+
+	event := &outbox.Event{ID: "e1", EventKey: "order.created", Payload: []byte(`{}`)}
+
+	if err := session.Publish(ctx, event); !errors.Is(err, outbox.ErrUnroutable) {
+		t.Fatalf("first publish = %v, want ErrUnroutable from the webhook destination", err)
+	}
+	webhookSub := webhooks.Subscribe("#", 10)
+	if err := session.Publish(ctx, event); err != nil {
+		t.Fatalf("retry = %v", err)
+	}
+
+	if n := len(brokerSub.Messages()); n != 1 {
+		t.Errorf("broker received %d copies, want 1", n)
+	}
+	if n := len(webhookSub.Messages()); n != 1 {
+		t.Errorf("webhook received %d copies, want 1", n)
+	}
+	if got := tracker.delivered["e1"]; len(got) != 2 {
+		t.Errorf("delivered = %v, want both destinations", got)
+	}
+}
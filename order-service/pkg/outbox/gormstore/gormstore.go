@@ -0,0 +1,338 @@
+// Package gormstore is an outbox.Store on top of GORM and Postgres. It expects
+// the outbox_events table and indexes from schema.sql, payloads are stored as
+// BYTEA next to their content type and schema reference, and encrypted at
+// rest when a Cipher is configured.
+package gormstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"gorm.io/gorm"
+)
+
+const (
+	table = "outbox_events"
+
+	// maxFailureReason is the length of the failure_reason column, VARCHAR(128).
+	// The full error stays in the dead-letter envelope and the relay's logs.
+	maxFailureReason = 128
+)
+
+type Store struct {
+	db            *gorm.DB
+	lockLease     time.Duration
+	notifyChannel string
+	cipher        outbox.Cipher
+	validate      func(event *outbox.Event) error
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithLockLease sets how long a claim stays valid without being renewed.
+// Claims older than that are taken over by other relays. Defaults to 30s.
+func WithLockLease(d time.Duration) Option {
+	return func(s *Store) { s.lockLease = d }
+}
+
+// WithNotify makes Insert send a pg_notify on channel with the event ID,
+// delivered once the producer's transaction commits.
+func WithNotify(channel string) Option {
+	return func(s *Store) { s.notifyChannel = channel }
+}
+
+// WithCipher makes Insert encrypt payloads before they are written.
+func WithCipher(c outbox.Cipher) Option {
+	return func(s *Store) { s.cipher = c }
+}
+
+// WithValidator makes Insert reject events validate returns an error for, e.g.
+// payloads that do not match their schema. The error is returned as is and
+// nothing is written. Events that are already encrypted are not validated.
+func WithValidator(validate func(event *outbox.Event) error) Option {
+	return func(s *Store) { s.validate = validate }
+}
+
+func New(db *gorm.DB, opts ...Option) *Store {
+	s := &Store{db: db, lockLease: 30 * time.Second}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// row is the part of an outbox_events row a relay needs.
+type row struct {
+	ID          string
+	EventKey    string
+	AggregateID string
+	Sequence    int64
+	Payload     []byte
+	ContentType string
+	SchemaRef   string
+	KeyID       string
+	WrappedKey  []byte
+	Scope       string `gorm:"column:encryption_scope"`
+	RetryCount  int
+	LockedBy    string
+	Traceparent string
+	CreatedAt   time.Time
+}
+
+// Insert adds event within tx, which must be a *gorm.DB transaction. Events
+// with an aggregate get the next sequence of that aggregate.
+func (s *Store) Insert(ctx context.Context, tx any, event *outbox.Event) error {
+	db, ok := tx.(*gorm.DB)
+	if !ok || db == nil {
+		return fmt.Errorf("gormstore: tx must be a *gorm.DB, got %T", tx)
+	}
+	if event.ID == "" || event.EventKey == "" {
+		return errors.New("gormstore: event needs an ID and an event key")
+	}
+	if s.validate != nil && event.Encryption == nil {
+		if err := s.validate(event); err != nil {
+			return err
+		}
+	}
+	db = db.WithContext(ctx)
+
+	if event.AggregateID != "" && event.Sequence == 0 {
+		sequence, err := nextSequence(db, event.AggregateID)
+		if err != nil {
+			return err
+		}
+		event.Sequence = sequence
+	}
+
+	payload, encryption := event.Payload, event.Encryption
+	if s.cipher != nil && encryption == nil {
+		var err error
+		if payload, encryption, err = s.cipher.Encrypt(event); err != nil {
+			return fmt.Errorf("gormstore: encrypt payload: %w", err)
+		}
+	}
+	if encryption == nil {
+		encryption = &outbox.Encryption{}
+	}
+
+	err := db.Exec(
+		`INSERT INTO `+table+` (id, event_key, aggregate_id, sequence, payload, content_type, schema_ref, key_id, wrapped_key, encryption_scope, status, traceparent)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.ID,
+		event.EventKey,
+		event.AggregateID,
+		event.Sequence,
+		payload,
+		event.PayloadContentType(),
+		event.SchemaRef,
+		encryption.KeyID,
+		encryption.WrappedKey,
+		encryption.Scope,
+		outbox.StatusPending,
+		event.Traceparent,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	if s.notifyChannel == "" {
+		return nil
+	}
+
+	// Postgres only delivers the notification once the business transaction commits.
+	return db.Exec("SELECT pg_notify(?, ?)", s.notifyChannel, event.ID).Error
+}
+
+// nextSequence serializes concurrent writers of the same aggregate with a
+// transaction-scoped advisory lock, so sequences never collide.
+func nextSequence(tx *gorm.DB, aggregateID string) (int64, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", aggregateID).Error; err != nil {
+		return 0, err
+	}
+
+	var sequence int64
+	err := tx.
+		Raw("SELECT COALESCE(MAX(sequence), 0) + 1 FROM "+table+" WHERE aggregate_id = ?", aggregateID).
+		Scan(&sequence).Error
+
+	return sequence, err
+}
+
+func (s *Store) Claim(ctx context.Context, owner string, limit int) ([]*outbox.Event, error) {
+	var rows []*row
+
+	// An event is only claimable while no earlier event of its aggregate is
+	// pending (including waiting for retry) or in progress.
+	query := `
+		UPDATE ` + table + `
+		SET
+			status = ?,
+			locked_at = NOW(),
+			locked_by = ?
+		WHERE id IN (
+				SELECT e.id
+				FROM ` + table + ` e
+				WHERE
+					(
+						e.status = ?
+						OR (
+							e.status = ?
+							AND e.locked_at < NOW() - make_interval(secs => ?)
+						)
+					)
+					AND (
+						e.next_retry_at IS NULL
+						OR e.next_retry_at <= NOW()
+					)
+					AND NOT EXISTS (
+						SELECT 1
+						FROM ` + table + ` prev
+						WHERE
+							e.aggregate_id <> ''
+							AND prev.aggregate_id = e.aggregate_id
+							AND prev.sequence < e.sequence
+							AND prev.status IN (?, ?)
+					)
+				ORDER BY e.created_at
+				LIMIT ?
+				FOR UPDATE OF e SKIP LOCKED
+		)
+		RETURNING id, event_key, aggregate_id, sequence, payload, content_type, schema_ref, key_id, wrapped_key, encryption_scope, retry_count, locked_by, traceparent, created_at`
+
+	err := s.db.WithContext(ctx).
+		Raw(query,
+			outbox.StatusInProgress,
+			owner,
+			outbox.StatusPending,
+			outbox.StatusInProgress,
+			s.lockLease.Seconds(),
+			outbox.StatusPending,
+			outbox.StatusInProgress,
+			limit,
+		).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*outbox.Event, 0, len(rows))
+	for _, r := range rows {
+		var encryption *outbox.Encryption
+		if r.KeyID != "" {
+			encryption = &outbox.Encryption{KeyID: r.KeyID, WrappedKey: r.WrappedKey, Scope: r.Scope}
+		}
+
+		events = append(events, &outbox.Event{
+			ID:          r.ID,
+			EventKey:    r.EventKey,
+			AggregateID: r.AggregateID,
+			Sequence:    r.Sequence,
+			Payload:     r.Payload,
+			ContentType: r.ContentType,
+			SchemaRef:   r.SchemaRef,
+			Traceparent: r.Traceparent,
+			Encryption:  encryption,
+			RetryCount:  r.RetryCount,
+			LockedBy:    r.LockedBy,
+			CreatedAt:   r.CreatedAt,
+		})
+	}
+
+	return events, nil
+}
+
+func (s *Store) UpdateState(ctx context.Context, event *outbox.Event, update *outbox.StateUpdate) (bool, error) {
+	columns := map[string]any{
+		"status":    update.Status,
+		"locked_at": nil,
+		"locked_by": nil,
+	}
+	switch update.Status {
+	case outbox.StatusPending:
+		columns["retry_count"] = update.RetryCount
+		columns["next_retry_at"] = update.NextRetryAt
+	case outbox.StatusFailed:
+		columns["failure_reason"] = truncate(update.FailureReason, maxFailureReason)
+		columns["failed_at"] = update.FailedAt
+	}
+
+	// Fenced by locked_by: a relay whose lease expired and was reclaimed by
+	// another relay must not overwrite the new owner's result.
+	result := s.db.WithContext(ctx).
+		Table(table).
+		Where("id = ? AND status = ? AND locked_by = ?", event.ID, outbox.StatusInProgress, event.LockedBy).
+		UpdateColumns(columns)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (s *Store) RenewLocks(ctx context.Context, owner string, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := s.db.WithContext(ctx).
+		Table(table).
+		Where("id IN ? AND status = ? AND locked_by = ?", ids, outbox.StatusInProgress, owner).
+		UpdateColumn("locked_at", gorm.Expr("NOW()"))
+
+	return result.RowsAffected, result.Error
+}
+
+func (s *Store) Count(ctx context.Context) (*outbox.Counts, error) {
+	counts := &outbox.Counts{}
+
+	err := s.db.WithContext(ctx).
+		Table(table).
+		Where(`
+			(
+				status = ?
+				OR (
+					status = ?
+					AND locked_at < NOW() - make_interval(secs => ?)
+				)
+			)
+			AND (
+				next_retry_at IS NULL
+				OR next_retry_at <= NOW()
+			)`,
+			outbox.StatusPending,
+			outbox.StatusInProgress,
+			s.lockLease.Seconds(),
+		).
+		Count(&counts.Backlog).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).
+		Table(table).
+		Where("status = ? AND next_retry_at IS NOT NULL AND next_retry_at > NOW()", outbox.StatusPending).
+		Count(&counts.WaitingRetry).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// truncate cuts s to at most n characters, the unit VARCHAR(n) is measured in.
+func truncate(s string, n int) string {
+	runes := 0
+	for i := range s {
+		if runes == n {
+			return s[:i]
+		}
+		runes++
+	}
+
+	return s
+}
@@ -0,0 +1,75 @@
+package gormstore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/gormstore"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunDB builds the statements without a database and hands their bound
+// values to capture.
+func dryRunDB(t *testing.T, capture func(vars []any)) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=outbox"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+
+	err = db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		capture(tx.Statement.Vars)
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	return db
+}
+
+func TestUpdateStateTruncatesFailureReason(t *testing.T) {
+	var vars []any
+	store := gormstore.New(dryRunDB(t, func(v []any) { vars = v }))
+
+	// A webhook error carries the endpoint and the net/http error text, well
+	// past what failure_reason holds.
+	reason := "webhook order-events (https://hooks.example.com/orders): " + strings.Repeat("connection reset by peer; ", 20) + "é"
+	event := &outbox.Event{ID: "evt-1", LockedBy: "relay-1"}
+	update := &outbox.StateUpdate{
+		Status:        outbox.StatusFailed,
+		FailureReason: reason,
+		FailedAt:      time.Now(),
+	}
+
+	if _, err := store.UpdateState(context.Background(), event, update); err != nil {
+		t.Fatalf("UpdateState: %v", err)
+	}
+
+	var stored string
+	for _, v := range vars {
+		if s, ok := v.(string); ok && strings.HasPrefix(s, "webhook order-events") {
+			stored = s
+		}
+	}
+	if stored == "" {
+		t.Fatalf("failure_reason not among the update's values %v", vars)
+	}
+	if n := utf8.RuneCountInString(stored); n != 128 {
+		t.Errorf("stored failure reason has %d characters, want 128", n)
+	}
+	if !strings.HasPrefix(reason, stored) {
+		t.Errorf("stored failure reason %q is not a prefix of the original", stored)
+	}
+}
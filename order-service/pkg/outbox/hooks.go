@@ -0,0 +1,115 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Outcome is the final result of relaying one event.
+type Outcome string
+
+const (
+	OutcomePublished  Outcome = "published"
+	OutcomeRetry      Outcome = "retry"
+	OutcomeDLQ        Outcome = "dlq"
+	OutcomeFailed     Outcome = "failed" // the outcome could not be recorded
+	OutcomeUnroutable Outcome = "unroutable"
+	OutcomePermanent  Outcome = "permanent"
+	OutcomeConnection Outcome = "connection"
+)
+
+const (
+	ClaimTriggerPoll   = "poll"
+	ClaimTriggerNotify = "notify" // Wake was called
+)
+
+// Hooks let callers observe the relay, e.g. to record metrics or traces.
+// Every field is optional.
+type Hooks struct {
+	// Claimed is called after every claim, also when nothing was due.
+	Claimed func(trigger string, count int)
+	// StartEvent may wrap the relaying of an event, e.g. in a tracing span.
+	// The returned function is called once the outcome was recorded.
+	StartEvent func(ctx context.Context, event *Event) (context.Context, func(Outcome))
+	// Published is called once the broker confirmed an event.
+	Published func(ctx context.Context, event *Event)
+	// PublishFailed is called for every failed publish attempt.
+	PublishFailed func(ctx context.Context, event *Event, class ErrorClass, err error)
+	// RetryScheduled is called when a failed event was put back to pending.
+	RetryScheduled func(ctx context.Context, event *Event, delay time.Duration)
+	// RetriesExhausted is called before an event is dead-lettered because its
+	// retry policy allows no further attempts.
+	RetriesExhausted func(ctx context.Context, event *Event)
+	// DeadLettered is called after an event was sent to the dead-letter
+	// destination; err is set when that failed.
+	DeadLettered func(ctx context.Context, event *Event, err error)
+	// LeasesRenewed reports the number of claims extended in one renewal.
+	LeasesRenewed func(count int64)
+	// LeaseLost is called when another relay took over an event's claim.
+	LeaseLost func(ctx context.Context, event *Event)
+	// Released reports claimed events handed back to pending by Stop.
+	Released func(count int)
+	// Backlog is called periodically with the counts of the store.
+	Backlog func(counts *Counts)
+}
+
+func (h *Hooks) claimed(trigger string, count int) {
+	if h.Claimed != nil {
+		h.Claimed(trigger, count)
+	}
+}
+
+func (h *Hooks) startEvent(ctx context.Context, event *Event) (context.Context, func(Outcome)) {
+	if h.StartEvent != nil {
+		return h.StartEvent(ctx, event)
+	}
+	return ctx, func(Outcome) {}
+}
+
+func (h *Hooks) published(ctx context.Context, event *Event) {
+	if h.Published != nil {
+		h.Published(ctx, event)
+	}
+}
+
+func (h *Hooks) publishFailed(ctx context.Context, event *Event, class ErrorClass, err error) {
+	if h.PublishFailed != nil {
+		h.PublishFailed(ctx, event, class, err)
+	}
+}
+
+func (h *Hooks) retryScheduled(ctx context.Context, event *Event, delay time.Duration) {
+	if h.RetryScheduled != nil {
+		h.RetryScheduled(ctx, event, delay)
+	}
+}
+
+func (h *Hooks) retriesExhausted(ctx context.Context, event *Event) {
+	if h.RetriesExhausted != nil {
+		h.RetriesExhausted(ctx, event)
+	}
+}
+
+func (h *Hooks) deadLettered(ctx context.Context, event *Event, err error) {
+	if h.DeadLettered != nil {
+		h.DeadLettered(ctx, event, err)
+	}
+}
+
+func (h *Hooks) leasesRenewed(count int64) {
+	if h.LeasesRenewed != nil {
+		h.LeasesRenewed(count)
+	}
+}
+
+func (h *Hooks) leaseLost(ctx context.Context, event *Event) {
+	if h.LeaseLost != nil {
+		h.LeaseLost(ctx, event)
+	}
+}
+
+func (h *Hooks) released(count int) {
+	if h.Released != nil {
+		h.Released(count)
+	}
+}
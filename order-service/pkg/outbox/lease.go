@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// leaseTracker holds the IDs of claimed events that have not reached a final
+// state yet, so their claims can be renewed.
+type leaseTracker struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+func newLeaseTracker() *leaseTracker {
+	return &leaseTracker{ids: map[string]struct{}{}}
+}
+
+func (t *leaseTracker) hold(events []*Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, event := range events {
+		t.ids[event.ID] = struct{}{}
+	}
+}
+
+func (t *leaseTracker) release(eventID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.ids, eventID)
+}
+
+func (t *leaseTracker) held() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]string, 0, len(t.ids))
+	for id := range t.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// renewLeases keeps the claims of every event this relay still holds fresh,
+// so slow publishes are not reclaimed by another relay.
+func (r *Relay) renewLeases(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.leaseRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ids := r.leases.held()
+			if len(ids) == 0 {
+				continue
+			}
+
+			renewed, err := r.store.RenewLocks(ctx, r.opts.owner, ids)
+			if err != nil {
+				r.opts.logger.Error("Failed to renew outbox event leases",
+					"error", err.Error(),
+					"count", len(ids),
+				)
+				continue
+			}
+			r.opts.hooks.leasesRenewed(renewed)
+		}
+	}
+}
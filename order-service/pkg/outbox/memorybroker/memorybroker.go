@@ -0,0 +1,171 @@
+// Package memorybroker is an in-process outbox.Publisher with topic routing,
+// for local development and for running a relay in tests without a broker.
+//
+// Routing keys and patterns are dot-separated words as with AMQP topic
+// exchanges: "*" matches exactly one word and "#" zero or more words.
+package memorybroker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+var (
+	ErrBrokerClosed = errors.New("memorybroker: broker closed")
+	ErrNoDeadLetter = errors.New("memorybroker: no dead-letter routing key configured")
+)
+
+// Message is what subscribers receive.
+type Message struct {
+	ID          string
+	RoutingKey  string
+	Body        []byte
+	ContentType string
+	SchemaRef   string
+	Headers     map[string]string // trace context of the publisher
+}
+
+type Broker struct {
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+
+	deadLetterKey string
+	mandatory     map[string]bool
+}
+
+// Option configures a Broker.
+type Option func(*Broker)
+
+// WithDeadLetter sets the routing key dead-lettered events are delivered
+// with. Without it DeadLetter fails permanently.
+func WithDeadLetter(routingKey string) Option {
+	return func(b *Broker) { b.deadLetterKey = routingKey }
+}
+
+// WithMandatory fails the confirm of the given event keys with
+// outbox.ErrUnroutable when no subscription matches them.
+func WithMandatory(eventKeys ...string) Option {
+	return func(b *Broker) {
+		for _, key := range eventKeys {
+			b.mandatory[key] = true
+		}
+	}
+}
+
+func New(opts ...Option) *Broker {
+	b := &Broker{
+		subscriptions: map[*Subscription]struct{}{},
+		mandatory:     map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Subscribe receives every message whose routing key matches pattern. A
+// confirm blocks while the subscription's buffer is full.
+func (b *Broker) Subscribe(pattern string, buffer int) *Subscription {
+	s := &Subscription{
+		broker:   b,
+		pattern:  strings.Split(pattern, "."),
+		messages: make(chan Message, buffer),
+		done:     make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		s.close()
+		return s
+	}
+	b.subscriptions[s] = struct{}{}
+
+	return s
+}
+
+// OpenSession never fails unless the broker was closed.
+func (b *Broker) OpenSession(ctx context.Context) (outbox.Session, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return nil, &outbox.PublishError{Class: outbox.ErrorClassConnection, Op: "session", Err: ErrBrokerClosed}
+	}
+
+	return &session{broker: b}, nil
+}
+
+// Close ends all subscriptions. Open sessions fail from now on.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	for s := range b.subscriptions {
+		s.close()
+		delete(b.subscriptions, s)
+	}
+
+	return nil
+}
+
+// route returns the subscriptions matching routingKey.
+func (b *Broker) route(routingKey string) ([]*Subscription, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+
+	words := strings.Split(routingKey, ".")
+
+	var matched []*Subscription
+	for s := range b.subscriptions {
+		if match(s.pattern, words) {
+			matched = append(matched, s)
+		}
+	}
+
+	return matched, nil
+}
+
+// match implements topic exchange matching of routing key words against
+// pattern words.
+func match(pattern, words []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			for i := 0; i <= len(words); i++ {
+				if match(pattern[1:], words[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(words) == 0 {
+				return false
+			}
+		default:
+			if len(words) == 0 || words[0] != pattern[0] {
+				return false
+			}
+		}
+
+		pattern, words = pattern[1:], words[1:]
+	}
+
+	return len(words) == 0
+}
@@ -0,0 +1,52 @@
+package memorybroker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"order.created", "order.created", true},
+		{"order.created", "order.updated", false},
+		{"order.created", "order.created.v2", false},
+
+		{"order.*", "order.created", true},
+		{"order.*", "order", false},
+		{"order.*", "order.created.v2", false},
+		{"*.created", "order.created", true},
+		{"*.*", "order.created", true},
+		{"*", "order.created", false},
+
+		{"#", "order.created", true},
+		{"#", "", true},
+		{"order.#", "order", true},
+		{"order.#", "order.created", true},
+		{"order.#", "order.created.v2", true},
+		{"order.#", "payment.created", false},
+		{"#.created", "order.created", true},
+		{"#.created", "created", true},
+		{"#.created", "order.created.v2", false},
+		{"order.#.v2", "order.created.v2", true},
+		{"order.#.v2", "order.v2", true},
+		{"order.#.v2", "order.created.v3", false},
+
+		{"*.#", "order", true},
+		{"*.#", "order.created.v2", true},
+		{"#.*", "order.created", true},
+		{"order.*.#", "order", false},
+		{"order.*.#", "order.created", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.key, func(t *testing.T) {
+			if got := match(strings.Split(tt.pattern, "."), strings.Split(tt.key, ".")); got != tt.want {
+				t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+			}
+		})
+	}
+}
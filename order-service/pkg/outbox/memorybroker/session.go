@@ -0,0 +1,95 @@
+package memorybroker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// session collects published messages and routes them on Confirm.
+type session struct {
+	broker  *Broker
+	pending []pendingMessage
+	closed  bool
+}
+
+type pendingMessage struct {
+	msg       Message
+	mandatory bool
+}
+
+func (s *session) Publish(ctx context.Context, event *outbox.Event) error {
+	msg := Message{
+		ID:          event.ID,
+		RoutingKey:  event.EventKey,
+		Body:        append([]byte(nil), event.Payload...),
+		ContentType: event.PayloadContentType(),
+		SchemaRef:   event.SchemaRef,
+	}
+
+	return s.publish(ctx, msg, s.broker.mandatory[event.EventKey])
+}
+
+func (s *session) DeadLetter(ctx context.Context, event *outbox.Event, cause error) error {
+	if s.broker.deadLetterKey == "" {
+		return &outbox.PublishError{Class: outbox.ErrorClassPermanent, Op: "dead-letter", Err: ErrNoDeadLetter}
+	}
+
+	body, err := json.Marshal(outbox.NewDeadLetterEnvelope(event, cause))
+	if err != nil {
+		return &outbox.PublishError{Class: outbox.ErrorClassPermanent, Op: "encode", Err: err}
+	}
+
+	msg := Message{ID: event.ID, RoutingKey: s.broker.deadLetterKey, Body: body, ContentType: outbox.ContentTypeJSON}
+	return s.publish(ctx, msg, false)
+}
+
+func (s *session) publish(ctx context.Context, msg Message, mandatory bool) error {
+	if s.closed {
+		return &outbox.PublishError{Class: outbox.ErrorClassConnection, Op: "publish", Err: ErrBrokerClosed}
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	msg.Headers = carrier
+	s.pending = append(s.pending, pendingMessage{msg: msg, mandatory: mandatory})
+	return nil
+}
+
+func (s *session) Confirm(ctx context.Context) error {
+	pending := s.pending
+	s.pending = nil
+
+	for _, p := range pending {
+		subscriptions, err := s.broker.route(p.msg.RoutingKey)
+		if err != nil {
+			return &outbox.PublishError{Class: outbox.ErrorClassConnection, Op: "confirm", Err: err}
+		}
+		if len(subscriptions) == 0 && p.mandatory {
+			return &outbox.PublishError{
+				Class: outbox.ErrorClassTransient,
+				Op:    "route",
+				Err:   fmt.Errorf("%w: no subscription matches routing key %q", outbox.ErrUnroutable, p.msg.RoutingKey),
+			}
+		}
+
+		for _, sub := range subscriptions {
+			if err := sub.deliver(ctx, p.msg); err != nil {
+				return &outbox.PublishError{Class: outbox.ErrorClassTransient, Op: "confirm", Err: err}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *session) Close() error {
+	s.closed = true
+	s.pending = nil
+	return nil
+}
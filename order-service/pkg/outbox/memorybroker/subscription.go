@@ -0,0 +1,62 @@
+package memorybroker
+
+import (
+	"context"
+	"sync"
+)
+
+type Subscription struct {
+	broker   *Broker
+	pattern  []string
+	messages chan Message
+
+	mu        sync.RWMutex // held by deliveries, so messages is never closed under them
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Messages is closed once the subscription or the broker is closed.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+func (s *Subscription) Unsubscribe() {
+	s.broker.mu.Lock()
+	delete(s.broker.subscriptions, s)
+	s.broker.mu.Unlock()
+
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.closeOnce.Do(func() {
+		// unblocks deliveries waiting for buffer space before taking the lock
+		close(s.done)
+
+		s.mu.Lock()
+		close(s.messages)
+		s.mu.Unlock()
+	})
+}
+
+// deliver blocks until msg was buffered, the subscription was closed or ctx
+// is done. Messages for a closed subscription are dropped.
+func (s *Subscription) deliver(ctx context.Context, msg Message) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+
+	select {
+	case s.messages <- msg:
+		return nil
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
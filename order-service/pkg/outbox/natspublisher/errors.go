@@ -0,0 +1,41 @@
+package natspublisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+func newPublishError(op string, err error) *outbox.PublishError {
+	class := classify(err)
+
+	switch {
+	case op == "encode":
+		class = outbox.ErrorClassPermanent
+	case errors.Is(err, context.DeadlineExceeded):
+		class = outbox.ErrorClassTransient
+	case isNoStream(err):
+		err = fmt.Errorf("%w: no stream bound to the subject: %v", outbox.ErrUnroutable, err)
+	}
+
+	return &outbox.PublishError{Class: class, Op: op, Err: err}
+}
+
+func classify(err error) outbox.ErrorClass {
+	if errors.Is(err, nats.ErrConnectionClosed) || errors.Is(err, nats.ErrConnectionDraining) {
+		return outbox.ErrorClassConnection
+	}
+
+	// Anything else, including an ack timeout while reconnecting, is worth a retry.
+	return outbox.ErrorClassTransient
+}
+
+// isNoStream reports whether no stream captured the subject, the JetStream
+// counterpart of an unroutable AMQP message.
+func isNoStream(err error) bool {
+	return errors.Is(err, nats.ErrNoResponders) || errors.Is(err, jetstream.ErrNoStreamResponse)
+}
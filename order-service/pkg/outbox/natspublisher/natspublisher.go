@@ -0,0 +1,71 @@
+// Package natspublisher is an outbox.Publisher for NATS JetStream. Event keys
+// map to subjects and the event ID is sent as Nats-Msg-Id, so the stream drops
+// duplicates of a republished event within its duplicate window.
+package natspublisher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+const (
+	HeaderContentType = "Content-Type"
+	HeaderSchemaRef   = "Schema-Ref" // set when the event has a schema reference
+)
+
+var ErrNoDeadLetter = errors.New("nats: no dead-letter subject configured")
+
+type Publisher struct {
+	js                jetstream.JetStream
+	subjectPrefix     string
+	deadLetterSubject string
+	timeout           time.Duration
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithSubjectPrefix is prepended to the event key to form the subject, e.g.
+// "events." publishes order.created to events.order.created.
+func WithSubjectPrefix(prefix string) Option {
+	return func(p *Publisher) { p.subjectPrefix = prefix }
+}
+
+// WithDeadLetter sets the subject DeadLetter publishes to.
+func WithDeadLetter(subject string) Option {
+	return func(p *Publisher) { p.deadLetterSubject = subject }
+}
+
+// WithPublishTimeout bounds waiting for the acks of a Confirm. Defaults to 5s.
+func WithPublishTimeout(d time.Duration) Option {
+	return func(p *Publisher) { p.timeout = d }
+}
+
+func New(js jetstream.JetStream, opts ...Option) *Publisher {
+	p := &Publisher{js: js, timeout: 5 * time.Second}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// OpenSession fails only when the connection was closed for good, JetStream
+// sessions share the connection and hold no state on the server.
+func (p *Publisher) OpenSession(ctx context.Context) (outbox.Session, error) {
+	if p.js.Conn().IsClosed() {
+		return nil, newPublishError("session", nats.ErrConnectionClosed)
+	}
+
+	return &session{publisher: p}, nil
+}
+
+// Subject returns the subject an event key is published to.
+func (p *Publisher) Subject(eventKey string) string {
+	return p.subjectPrefix + eventKey
+}
@@ -0,0 +1,213 @@
+package natspublisher_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/natspublisher"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// newJetStream starts an in-process server with JetStream and an EVENTS stream
+// capturing "events.>".
+func newJetStream(t *testing.T, cfg jetstream.StreamConfig) (jetstream.JetStream, jetstream.Stream) {
+	t.Helper()
+
+	opts := natsserver.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	srv := natsserver.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Name = "EVENTS"
+	cfg.Subjects = []string{"events.>"}
+	stream, err := js.CreateStream(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return js, stream
+}
+
+func publish(ctx context.Context, t *testing.T, p *natspublisher.Publisher, events ...*outbox.Event) error {
+	t.Helper()
+
+	session, err := p.OpenSession(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	for _, event := range events {
+		if err := session.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish = %v, errors must only surface on Confirm", err)
+		}
+	}
+	return session.Confirm(ctx)
+}
+
+func streamMsgs(t *testing.T, stream jetstream.Stream) uint64 {
+	t.Helper()
+
+	info, err := stream.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.State.Msgs
+}
+
+func TestPublishMapsEventToSubject(t *testing.T) {
+	ctx := context.Background()
+	js, stream := newJetStream(t, jetstream.StreamConfig{})
+	p := natspublisher.New(js, natspublisher.WithSubjectPrefix("events."))
+
+	event := &outbox.Event{
+		ID:          "e1",
+		EventKey:    "order.created",
+		Payload:     []byte(`{"id":1}`),
+		SchemaRef:   "order.created.v1",
+		Traceparent: traceparent,
+	}
+	if err := publish(ctx, t, p, event); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := stream.GetMsg(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "events.order.created" {
+		t.Errorf("subject = %q, want events.order.created", msg.Subject)
+	}
+	if string(msg.Data) != `{"id":1}` {
+		t.Errorf("data = %s", msg.Data)
+	}
+
+	headers := map[string]string{
+		nats.MsgIdHdr:                   "e1",
+		natspublisher.HeaderContentType: outbox.ContentTypeJSON,
+		natspublisher.HeaderSchemaRef:   "order.created.v1",
+		"traceparent":                   traceparent,
+	}
+	for name, want := range headers {
+		if got := msg.Header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPublishPrefersSpanOverStoredTraceparent(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	js, stream := newJetStream(t, jetstream.StreamConfig{})
+	p := natspublisher.New(js, natspublisher.WithSubjectPrefix("events."))
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	event := &outbox.Event{ID: "e1", EventKey: "order.created", Payload: []byte(`{}`), Traceparent: traceparent}
+	if err := publish(ctx, t, p, event); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := stream.GetMsg(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := msg.Header.Get("traceparent"), "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"; got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+}
+
+func TestPublishDeduplicatesByEventID(t *testing.T) {
+	ctx := context.Background()
+	js, stream := newJetStream(t, jetstream.StreamConfig{Duplicates: time.Minute})
+	p := natspublisher.New(js, natspublisher.WithSubjectPrefix("events."))
+
+	event := &outbox.Event{ID: "e1", EventKey: "order.created", Payload: []byte(`{}`)}
+
+	// a republished event, e.g. after a lost lease, is acked as a duplicate
+	for range 2 {
+		if err := publish(ctx, t, p, event); err != nil {
+			t.Fatalf("Confirm = %v, a duplicate ack must count as success", err)
+		}
+	}
+
+	if msgs := streamMsgs(t, stream); msgs != 1 {
+		t.Errorf("stream has %d messages, want 1", msgs)
+	}
+}
+
+func TestConfirmWaitsForPubAck(t *testing.T) {
+	ctx := context.Background()
+	js, stream := newJetStream(t, jetstream.StreamConfig{
+		MaxMsgs: 1,
+		Discard: jetstream.DiscardNew,
+	})
+	p := natspublisher.New(js, natspublisher.WithSubjectPrefix("events."))
+
+	first := &outbox.Event{ID: "e1", EventKey: "order.created", Payload: []byte(`{}`)}
+	if err := publish(ctx, t, p, first); err != nil {
+		t.Fatal(err)
+	}
+	// stored by the time Confirm returns, without waiting
+	if msgs := streamMsgs(t, stream); msgs != 1 {
+		t.Fatalf("stream has %d messages after Confirm, want 1", msgs)
+	}
+
+	// the stream rejects the second event, which only the PubAck tells
+	second := &outbox.Event{ID: "e2", EventKey: "order.created", Payload: []byte(`{}`)}
+	err := publish(ctx, t, p, second)
+	if err == nil {
+		t.Fatal("Confirm = nil, want the rejection of the full stream")
+	}
+	if class := outbox.Classify(err); class != outbox.ErrorClassTransient {
+		t.Errorf("class = %s, want transient", class)
+	}
+}
+
+func TestConfirmUnroutableWithoutStream(t *testing.T) {
+	ctx := context.Background()
+	js, _ := newJetStream(t, jetstream.StreamConfig{})
+	p := natspublisher.New(js, natspublisher.WithSubjectPrefix("unbound."))
+
+	event := &outbox.Event{ID: "e1", EventKey: "order.created", Payload: []byte(`{}`)}
+	err := publish(ctx, t, p, event)
+	if !errors.Is(err, outbox.ErrUnroutable) {
+		t.Fatalf("Confirm = %v, want ErrUnroutable", err)
+	}
+	if class := outbox.Classify(err); class != outbox.ErrorClassTransient {
+		t.Errorf("class = %s, want transient", class)
+	}
+}
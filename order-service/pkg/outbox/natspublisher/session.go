@@ -0,0 +1,93 @@
+package natspublisher
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerTraceparent is the W3C trace context header.
+const headerTraceparent = "traceparent"
+
+type session struct {
+	publisher *Publisher
+	pending   []jetstream.PubAckFuture
+}
+
+func (s *session) Publish(ctx context.Context, event *outbox.Event) error {
+	msg := &nats.Msg{Subject: s.publisher.Subject(event.EventKey), Data: event.Payload, Header: nats.Header{}}
+	msg.Header.Set(HeaderContentType, event.PayloadContentType())
+	if event.SchemaRef != "" {
+		msg.Header.Set(HeaderSchemaRef, event.SchemaRef)
+	}
+
+	return s.publish(ctx, msg, event.ID, event.Traceparent)
+}
+
+func (s *session) DeadLetter(ctx context.Context, event *outbox.Event, cause error) error {
+	if s.publisher.deadLetterSubject == "" {
+		return &outbox.PublishError{Class: outbox.ErrorClassPermanent, Op: "dead-letter", Err: ErrNoDeadLetter}
+	}
+
+	body, err := json.Marshal(outbox.NewDeadLetterEnvelope(event, cause))
+	if err != nil {
+		return newPublishError("encode", err)
+	}
+
+	msg := &nats.Msg{Subject: s.publisher.deadLetterSubject, Data: body, Header: nats.Header{}}
+	msg.Header.Set(HeaderContentType, outbox.ContentTypeJSON)
+
+	// Dead-lettered and regular copies of an event must not dedupe each other.
+	return s.publish(ctx, msg, event.ID+":dlq", event.Traceparent)
+}
+
+// publish propagates the trace of ctx, or traceparent when ctx has no span,
+// so consumers still join the trace of the request that produced the event.
+func (s *session) publish(ctx context.Context, msg *nats.Msg, msgID, traceparent string) error {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(msg.Header))
+	} else if traceparent != "" {
+		msg.Header.Set(headerTraceparent, traceparent)
+	}
+
+	future, err := s.publisher.js.PublishMsgAsync(msg, jetstream.WithMsgID(msgID))
+	if err != nil {
+		return newPublishError("publish", err)
+	}
+
+	s.pending = append(s.pending, future)
+	return nil
+}
+
+// Confirm waits for the stream's ack of every pending publish. An ack for a
+// duplicate counts as success, the stream already has the event.
+func (s *session) Confirm(ctx context.Context) error {
+	pending := s.pending
+	s.pending = nil
+
+	ctx, cancel := context.WithTimeout(ctx, s.publisher.timeout)
+	defer cancel()
+
+	for _, future := range pending {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			return newPublishError("confirm", err)
+		case <-ctx.Done():
+			return newPublishError("confirm", ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+func (s *session) Close() error {
+	s.pending = nil
+	return nil
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+type options struct {
+	owner              string
+	interval           time.Duration
+	batchSize          int
+	concurrency        int
+	leaseRenewInterval time.Duration
+	backlogInterval    time.Duration
+	retryPolicies      *RetryPolicies
+	hooks              Hooks
+	cipher             Cipher
+	logger             *slog.Logger
+}
+
+// Option configures a Relay.
+type Option func(*options)
+
+func defaultOptions() options {
+	owner, _ := os.Hostname()
+
+	return options{
+		owner:              owner,
+		interval:           time.Second,
+		batchSize:          100,
+		concurrency:        1,
+		leaseRenewInterval: 10 * time.Second,
+		backlogInterval:    10 * time.Second,
+		retryPolicies: &RetryPolicies{
+			Default: &ExponentialRetryPolicy{retryLimits{base: time.Second, maxDelay: 5 * time.Minute, maxAttempts: 3}},
+		},
+		logger: slog.Default(),
+	}
+}
+
+// WithOwner sets the name claims are taken under. It must be unique per
+// relay and defaults to the hostname.
+func WithOwner(owner string) Option {
+	return func(o *options) { o.owner = owner }
+}
+
+// WithInterval sets how often the store is polled for due events.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) { o.interval = d }
+}
+
+// WithBatchSize sets the maximum number of events claimed at once.
+func WithBatchSize(n int) Option {
+	return func(o *options) { o.batchSize = n }
+}
+
+// WithConcurrency sets the number of workers publishing in parallel.
+func WithConcurrency(n int) Option {
+	return func(o *options) { o.concurrency = n }
+}
+
+// WithLeaseRenewInterval sets how often claims of in-flight events are
+// extended. It must be well below the store's lock lease.
+func WithLeaseRenewInterval(d time.Duration) Option {
+	return func(o *options) { o.leaseRenewInterval = d }
+}
+
+// WithBacklogInterval sets how often Hooks.Backlog is called, zero disables it.
+func WithBacklogInterval(d time.Duration) Option {
+	return func(o *options) { o.backlogInterval = d }
+}
+
+// WithRetryPolicies replaces the default of three exponential retries
+// between one second and five minutes.
+func WithRetryPolicies(p *RetryPolicies) Option {
+	return func(o *options) { o.retryPolicies = p }
+}
+
+func WithHooks(h Hooks) Option {
+	return func(o *options) { o.hooks = h }
+}
+
+// WithCipher decrypts events that were encrypted at rest right before they
+// are published.
+func WithCipher(c Cipher) Option {
+	return func(o *options) { o.cipher = c }
+}
+
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) { o.logger = l }
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Publisher connects the relay to a broker. Every relay worker opens its own
+// Session, so a transport never has to share its channels between goroutines.
+type Publisher interface {
+	// OpenSession is called again after a session failed with an
+	// ErrorClassConnection error.
+	OpenSession(ctx context.Context) (Session, error)
+}
+
+// Session publishes on behalf of one worker and is not safe for concurrent
+// use. Failures should be a *PublishError so the relay knows how to react.
+type Session interface {
+	// Publish hands the event to the broker, routed by its event key. It may
+	// return before the broker accepted it.
+	Publish(ctx context.Context, event *Event) error
+	// DeadLetter hands an event that will not be retried, wrapped in a
+	// DeadLetterEnvelope, to the broker's dead-letter destination.
+	DeadLetter(ctx context.Context, event *Event, cause error) error
+	// Confirm returns once the broker took responsibility for everything
+	// published since the last Confirm, or the first failure among it.
+	Confirm(ctx context.Context) error
+	// Close releases the session. Unconfirmed events may be lost.
+	Close() error
+}
+
+// ErrorClass tells the relay how to react to a failed publish.
+type ErrorClass string
+
+const (
+	// ErrorClassPermanent will fail the same way on every retry, e.g. a
+	// payload that cannot be encoded. The event is dead-lettered right away.
+	ErrorClassPermanent ErrorClass = "permanent"
+	// ErrorClassTransient may succeed when retried later, e.g. a nack or a
+	// confirm timeout. It consumes the event's retry budget.
+	ErrorClassTransient ErrorClass = "transient"
+	// ErrorClassConnection means the connection to the broker is gone. The
+	// event itself is fine and is released without consuming a retry.
+	ErrorClassConnection ErrorClass = "connection"
+)
+
+// ErrUnroutable is wrapped by publishers when the broker accepted an event
+// but had nowhere to deliver it.
+var ErrUnroutable = errors.New("unroutable")
+
+// PublishError is returned by publishers for every failure.
+type PublishError struct {
+	Class ErrorClass
+	Op    string // e.g. encode | publish | confirm | route
+	Err   error
+	// RetryPolicy, when set, replaces the event key's policy for this
+	// failure, e.g. the policy of the destination that failed.
+	RetryPolicy RetryPolicy
+}
+
+func (e *PublishError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Op, e.Class, e.Err)
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the class of a publish error. Errors that are not a
+// *PublishError are treated as transient.
+func Classify(err error) ErrorClass {
+	var publishErr *PublishError
+	if errors.As(err, &publishErr) {
+		return publishErr.Class
+	}
+
+	return ErrorClassTransient
+}
+
+// DeadLetterEnvelope is the body of every dead-lettered event. JSON payloads
+// are embedded as is, any other payload is base64 encoded in payload_base64.
+type DeadLetterEnvelope struct {
+	EventID       string          `json:"event_id"`
+	EventKey      string          `json:"event_key"`
+	AggregateID   string          `json:"aggregate_id,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	PayloadBase64 []byte          `json:"payload_base64,omitempty"`
+	ContentType   string          `json:"content_type,omitempty"`
+	SchemaRef     string          `json:"schema_ref,omitempty"`
+	Traceparent   string          `json:"traceparent,omitempty"`
+	FailedAt      time.Time       `json:"failed_at"`
+	FailureReason string          `json:"failure_reason"`
+}
+
+func NewDeadLetterEnvelope(event *Event, cause error) *DeadLetterEnvelope {
+	envelope := &DeadLetterEnvelope{
+		EventID:       event.ID,
+		EventKey:      event.EventKey,
+		AggregateID:   event.AggregateID,
+		ContentType:   event.ContentType,
+		SchemaRef:     event.SchemaRef,
+		Traceparent:   event.Traceparent,
+		FailedAt:      time.Now(),
+		FailureReason: cause.Error(),
+	}
+	if IsJSON(event.PayloadContentType()) && json.Valid(event.Payload) {
+		envelope.Payload = json.RawMessage(event.Payload)
+	} else {
+		envelope.PayloadBase64 = event.Payload
+	}
+
+	return envelope
+}
+
+// EventPayload returns the payload of the dead-lettered event.
+func (e *DeadLetterEnvelope) EventPayload() []byte {
+	if e.PayloadBase64 != nil {
+		return e.PayloadBase64
+	}
+	return e.Payload
+}
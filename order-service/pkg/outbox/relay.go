@@ -0,0 +1,263 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+var ErrRelayRunning = errors.New("outbox: relay is already running")
+
+// Relay moves events from a Store to a Publisher.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	opts      options
+	wakeCh    chan struct{}
+	leases    *leaseTracker
+	stopping  chan struct{} // closed by Stop, no new events are claimed
+	stopOnce  sync.Once
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{} // set while Run is active
+}
+
+// New returns an error when a store or publisher is missing or an option is
+// out of range.
+func New(store Store, publisher Publisher, opts ...Option) (*Relay, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if store == nil || publisher == nil {
+		return nil, errors.New("outbox: relay needs a store and a publisher")
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		opts:      o,
+		wakeCh:    make(chan struct{}, 1),
+		leases:    newLeaseTracker(),
+		stopping:  make(chan struct{}),
+	}, nil
+}
+
+func (o *options) validate() error {
+	switch {
+	case o.interval <= 0:
+		return fmt.Errorf("outbox: interval must be positive, got %s", o.interval)
+	case o.batchSize < 1:
+		return fmt.Errorf("outbox: batch size must be at least 1, got %d", o.batchSize)
+	case o.concurrency < 1:
+		return fmt.Errorf("outbox: concurrency must be at least 1, got %d", o.concurrency)
+	case o.leaseRenewInterval <= 0:
+		return fmt.Errorf("outbox: lease renew interval must be positive, got %s", o.leaseRenewInterval)
+	case o.retryPolicies == nil || o.retryPolicies.Default == nil:
+		return errors.New("outbox: retry policies need a default policy")
+	case o.logger == nil:
+		return errors.New("outbox: logger must not be nil")
+	}
+
+	return nil
+}
+
+// Run relays events until ctx is done or Stop is called. It may be called
+// again after it returned, e.g. when leadership was regained, but not after Stop.
+func (r *Relay) Run(ctx context.Context) error {
+	r.mu.Lock()
+	if r.done != nil {
+		r.mu.Unlock()
+		return ErrRelayRunning
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	r.cancel, r.done = cancel, done
+	r.mu.Unlock()
+
+	defer func() {
+		cancel()
+		r.mu.Lock()
+		r.cancel, r.done = nil, nil
+		r.mu.Unlock()
+		close(done)
+	}()
+
+	if r.isStopping() {
+		return nil
+	}
+
+	r.run(ctx)
+
+	if r.isStopping() {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// Stop stops claiming new events, waits for in-flight events to finish and
+// hands claimed but unstarted events back to pending. Publishes still running
+// when ctx is done are aborted; their events are reclaimed once the lease
+// expires. A stopped relay cannot be run again.
+func (r *Relay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stopping) })
+
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Wake claims due events right away instead of at the next interval, e.g.
+// when the store signalled a new event. Pending wake-ups are coalesced.
+func (r *Relay) Wake() {
+	select {
+	case r.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Relay) isStopping() bool {
+	select {
+	case <-r.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *Relay) run(ctx context.Context) {
+	r.opts.logger.Info("Outbox relay started", "owner", r.opts.owner)
+
+	// Each worker has its own queue so events of one aggregate stay on one worker.
+	queues := make([]chan *Event, r.opts.concurrency)
+	wg := sync.WaitGroup{}
+
+	for i := range queues {
+		queues[i] = make(chan *Event, 1)
+		wg.Add(1)
+		go func(queue <-chan *Event) {
+			defer wg.Done()
+			r.work(ctx, queue)
+		}(queues[i])
+	}
+
+	go r.renewLeases(ctx)
+	if r.opts.hooks.Backlog != nil && r.opts.backlogInterval > 0 {
+		go r.reportBacklog(ctx)
+	}
+
+	// The ticker is a safety net for missed wake-ups and retries coming due.
+	ticker := time.NewTicker(r.opts.interval)
+	defer ticker.Stop()
+
+	// Workers finish the event they are publishing, anything still queued is released.
+	drain := func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			drain()
+			return
+
+		case <-r.stopping:
+			drain()
+			return
+
+		case <-ticker.C:
+			r.dispatch(ctx, queues, ClaimTriggerPoll)
+
+		case <-r.wakeCh:
+			// a full batch means more events are likely waiting
+			if r.dispatch(ctx, queues, ClaimTriggerNotify) == r.opts.batchSize {
+				r.Wake()
+			}
+		}
+	}
+}
+
+func (r *Relay) dispatch(ctx context.Context, queues []chan *Event, trigger string) int {
+	events, err := r.store.Claim(ctx, r.opts.owner, r.opts.batchSize)
+	if err != nil {
+		r.opts.logger.ErrorContext(ctx, "Failed to claim outbox events", "error", err.Error())
+		return 0
+	}
+
+	r.opts.hooks.claimed(trigger, len(events))
+	if len(events) == 0 {
+		return 0
+	}
+
+	r.leases.hold(events)
+	r.opts.logger.Info("Claimed outbox events", "count", len(events), "trigger", trigger)
+
+	for i, event := range events {
+		select {
+		case <-ctx.Done():
+			return len(events)
+		case <-r.stopping:
+			r.release(ctx, events[i:])
+			return len(events)
+		case queues[workerFor(event, len(queues))] <- event:
+		}
+	}
+
+	return len(events)
+}
+
+func (r *Relay) reportBacklog(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.backlogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			counts, err := r.store.Count(ctx)
+			if err != nil {
+				r.opts.logger.Warn("Failed to count outbox backlog", "error", err.Error())
+				continue
+			}
+			r.opts.hooks.Backlog(counts)
+		}
+	}
+}
+
+// workerFor pins every event of an aggregate to the same worker, while events
+// without an aggregate are spread across workers by their ID.
+func workerFor(event *Event, workers int) int {
+	key := event.AggregateID
+	if key == "" {
+		key = event.ID
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
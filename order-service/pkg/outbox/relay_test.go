@@ -0,0 +1,286 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox/memorybroker"
+)
+
+func newTestRelay(t *testing.T, store outbox.Store, broker *memorybroker.Broker, opts ...outbox.Option) *outbox.Relay {
+	t.Helper()
+
+	policies, err := outbox.NewRetryPolicies("fixed:10ms:10ms:2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts = append([]outbox.Option{
+		outbox.WithOwner("test-relay"),
+		outbox.WithInterval(10 * time.Millisecond),
+		outbox.WithLeaseRenewInterval(time.Second),
+		outbox.WithRetryPolicies(policies),
+		outbox.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}, opts...)
+
+	relay, err := outbox.New(store, broker, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return relay
+}
+
+// runRelay runs relay until the test ends.
+func runRelay(t *testing.T, relay *outbox.Relay) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = relay.Run(context.Background())
+	}()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = relay.Stop(ctx)
+		<-done
+	})
+}
+
+// receive returns the next n messages of sub.
+func receive(t *testing.T, sub *memorybroker.Subscription, n int) []memorybroker.Message {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	messages := make([]memorybroker.Message, 0, n)
+	for len(messages) < n {
+		select {
+		case msg := <-sub.Messages():
+			messages = append(messages, msg)
+		case <-timeout:
+			t.Fatalf("received %d of %d messages", len(messages), n)
+		}
+	}
+	return messages
+}
+
+func TestRelayKeepsAggregateOrder(t *testing.T) {
+	const perAggregate = 5
+	aggregates := []string{"a", "b", "c"}
+
+	var events []*outbox.Event
+	for i := 1; i <= perAggregate; i++ {
+		for _, aggregate := range aggregates {
+			events = append(events, &outbox.Event{
+				ID:          fmt.Sprintf("%s-%d", aggregate, i),
+				EventKey:    "order.updated",
+				AggregateID: aggregate,
+				Payload:     []byte(`{}`),
+			})
+		}
+	}
+
+	store := newMemStore(events...)
+	broker := memorybroker.New()
+	sub := broker.Subscribe("order.#", len(events))
+
+	runRelay(t, newTestRelay(t, store, broker, outbox.WithConcurrency(4)))
+
+	next := map[string]int{}
+	for _, msg := range receive(t, sub, len(events)) {
+		aggregate, seq, _ := strings.Cut(msg.ID, "-")
+		next[aggregate]++
+		if want := fmt.Sprintf("%d", next[aggregate]); seq != want {
+			t.Fatalf("aggregate %s: got event %s, want sequence %s", aggregate, msg.ID, want)
+		}
+	}
+
+	eventually(t, "all events published", func() bool {
+		return store.countStatus(outbox.StatusPublished) == len(events)
+	})
+}
+
+func TestRelayDeadLettersAfterRetries(t *testing.T) {
+	store := newMemStore(&outbox.Event{ID: "e1", EventKey: "order.created", Payload: []byte(`{"id":1}`)})
+	broker := memorybroker.New(
+		memorybroker.WithMandatory("order.created"),
+		memorybroker.WithDeadLetter("dlq"),
+	)
+	dlq := broker.Subscribe("dlq", 1)
+
+	var (
+		mu       sync.Mutex
+		failures int
+		retries  int
+	)
+	hooks := outbox.Hooks{
+		PublishFailed: func(ctx context.Context, event *outbox.Event, class outbox.ErrorClass, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures++
+		},
+		RetryScheduled: func(ctx context.Context, event *outbox.Event, delay time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			retries++
+		},
+	}
+
+	runRelay(t, newTestRelay(t, store, broker, outbox.WithHooks(hooks)))
+
+	msg := receive(t, dlq, 1)[0]
+
+	var envelope outbox.DeadLetterEnvelope
+	if err := json.Unmarshal(msg.Body, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.EventID != "e1" || string(envelope.Payload) != `{"id":1}` {
+		t.Errorf("envelope = %+v", envelope)
+	}
+	if !strings.Contains(envelope.FailureReason, outbox.ErrUnroutable.Error()) {
+		t.Errorf("failure reason = %q, want it to mention %q", envelope.FailureReason, outbox.ErrUnroutable)
+	}
+
+	eventually(t, "event marked failed", func() bool {
+		status, _, _ := store.state("e1")
+		return status == outbox.StatusFailed
+	})
+	if _, retryCount, _ := store.state("e1"); retryCount != 2 {
+		t.Errorf("retry count = %d, want 2", retryCount)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if failures != 3 || retries != 2 {
+		t.Errorf("failures = %d, retries = %d, want 3 and 2", failures, retries)
+	}
+}
+
+func TestRelayRetriesUnroutableMandatoryEvent(t *testing.T) {
+	store := newMemStore(
+		&outbox.Event{ID: "created", EventKey: "order.created", Payload: []byte(`{}`)},
+		&outbox.Event{ID: "shipped", EventKey: "order.shipped", Payload: []byte(`{}`)},
+	)
+	broker := memorybroker.New(memorybroker.WithMandatory("order.created"))
+
+	unroutable := make(chan error, 10)
+	hooks := outbox.Hooks{
+		PublishFailed: func(ctx context.Context, event *outbox.Event, class outbox.ErrorClass, err error) {
+			if class != outbox.ErrorClassTransient {
+				t.Errorf("class = %s, want transient", class)
+			}
+			unroutable <- err
+		},
+	}
+
+	runRelay(t, newTestRelay(t, store, broker, outbox.WithHooks(hooks)))
+
+	select {
+	case err := <-unroutable:
+		if !errors.Is(err, outbox.ErrUnroutable) {
+			t.Fatalf("publish error = %v, want ErrUnroutable", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mandatory event was not reported unroutable")
+	}
+
+	// keys that are not mandatory are dropped like on an AMQP exchange
+	eventually(t, "non-mandatory event published", func() bool {
+		status, _, _ := store.state("shipped")
+		return status == outbox.StatusPublished
+	})
+
+	sub := broker.Subscribe("order.created", 1)
+	if msg := receive(t, sub, 1)[0]; msg.ID != "created" {
+		t.Errorf("received %s, want created", msg.ID)
+	}
+
+	eventually(t, "mandatory event published", func() bool {
+		status, _, _ := store.state("created")
+		return status == outbox.StatusPublished
+	})
+}
+
+func TestRelayStopReleasesUnstartedEvents(t *testing.T) {
+	ids := []string{"e1", "e2", "e3"}
+
+	var events []*outbox.Event
+	for _, id := range ids {
+		events = append(events, &outbox.Event{ID: id, EventKey: "order.created", Payload: []byte(`{}`)})
+	}
+
+	store := newMemStore(events...)
+	broker := memorybroker.New()
+	// never read, so the first confirm blocks until Stop gives up on it
+	broker.Subscribe("order.created", 0)
+
+	var (
+		mu       sync.Mutex
+		released int
+	)
+	started := make(chan string, len(ids))
+	hooks := outbox.Hooks{
+		StartEvent: func(ctx context.Context, event *outbox.Event) (context.Context, func(outbox.Outcome)) {
+			started <- event.ID
+			return ctx, func(outbox.Outcome) {}
+		},
+		Released: func(count int) {
+			mu.Lock()
+			defer mu.Unlock()
+			released += count
+		},
+	}
+
+	relay := newTestRelay(t, store, broker, outbox.WithConcurrency(1), outbox.WithHooks(hooks))
+
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(context.Background()) }()
+
+	eventually(t, "all events claimed", func() bool {
+		return store.countStatus(outbox.StatusInProgress) == len(ids)
+	})
+	inFlight := <-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := relay.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop = %v, want deadline exceeded for the blocked publish", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Run = %v, want nil after Stop", err)
+	}
+
+	for _, id := range ids {
+		status, retryCount, lockedBy := store.state(id)
+		if status != outbox.StatusPending || lockedBy != "" {
+			t.Errorf("%s: status %s locked by %q, want pending and unlocked", id, status, lockedBy)
+		}
+		// only the aborted publish counts as an attempt
+		want := 0
+		if id == inFlight {
+			want = 1
+		}
+		if retryCount != want {
+			t.Errorf("%s: retry count %d, want %d", id, retryCount, want)
+		}
+	}
+	if len(started) > 0 {
+		t.Errorf("event %s started after Stop", <-started)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if released != len(ids)-1 {
+		t.Errorf("released %d events, want %d", released, len(ids)-1)
+	}
+}
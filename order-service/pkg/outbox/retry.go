@@ -0,0 +1,202 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RetryPolicyFixed        = "fixed"
+	RetryPolicyLinear       = "linear"
+	RetryPolicyExponential  = "exponential"
+	RetryPolicyDecorrelated = "decorrelated"
+)
+
+// RetryPolicy decides how often and how long apart a failed publish is retried.
+type RetryPolicy interface {
+	// MaxAttempts is the number of retries after the first failed publish.
+	MaxAttempts() int
+	// Delay is the wait before the given retry, starting at 1. It never
+	// exceeds the policy's max delay.
+	Delay(attempt int) time.Duration
+}
+
+type retryLimits struct {
+	base        time.Duration
+	maxDelay    time.Duration
+	maxAttempts int
+}
+
+func (l retryLimits) MaxAttempts() int {
+	return l.maxAttempts
+}
+
+// FixedRetryPolicy waits base before every retry.
+type FixedRetryPolicy struct{ retryLimits }
+
+func (p *FixedRetryPolicy) Delay(attempt int) time.Duration {
+	return min(p.base, p.maxDelay)
+}
+
+// LinearRetryPolicy waits attempt * base.
+type LinearRetryPolicy struct{ retryLimits }
+
+func (p *LinearRetryPolicy) Delay(attempt int) time.Duration {
+	if attempt > 0 && p.base > p.maxDelay/time.Duration(attempt) {
+		return p.maxDelay
+	}
+	return time.Duration(max(attempt, 1)) * p.base
+}
+
+// ExponentialRetryPolicy waits base * 2^(attempt-1) plus up to a second of
+// jitter, capped at max delay.
+type ExponentialRetryPolicy struct{ retryLimits }
+
+func (p *ExponentialRetryPolicy) Delay(attempt int) time.Duration {
+	d := p.base
+	for i := 1; i < attempt && d < p.maxDelay; i++ {
+		d *= 2
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(time.Second)))
+	return min(d+jitter, p.maxDelay)
+}
+
+// DecorrelatedRetryPolicy implements "decorrelated jitter": every delay is
+// random between base and three times the previous one, capped at max delay.
+// The chain is replayed from the first retry so no state has to be stored.
+type DecorrelatedRetryPolicy struct{ retryLimits }
+
+func (p *DecorrelatedRetryPolicy) Delay(attempt int) time.Duration {
+	d := p.base
+	for i := 0; i < attempt; i++ {
+		upper := min(d*3, p.maxDelay)
+		if upper <= p.base {
+			return min(p.base, p.maxDelay)
+		}
+		d = p.base + time.Duration(rand.Int63n(int64(upper-p.base)))
+	}
+	return min(d, p.maxDelay)
+}
+
+// NewRetryPolicy returns the policy of the given kind.
+func NewRetryPolicy(kind string, base, maxDelay time.Duration, maxAttempts int) (RetryPolicy, error) {
+	if base <= 0 || maxDelay < base || maxAttempts < 0 {
+		return nil, fmt.Errorf("retry policy: need 0 < base <= maxDelay and maxAttempts >= 0")
+	}
+
+	limits := retryLimits{base: base, maxDelay: maxDelay, maxAttempts: maxAttempts}
+
+	switch kind {
+	case RetryPolicyFixed:
+		return &FixedRetryPolicy{limits}, nil
+	case RetryPolicyLinear:
+		return &LinearRetryPolicy{limits}, nil
+	case RetryPolicyExponential:
+		return &ExponentialRetryPolicy{limits}, nil
+	case RetryPolicyDecorrelated:
+		return &DecorrelatedRetryPolicy{limits}, nil
+	}
+
+	return nil, fmt.Errorf("retry policy: unknown kind %q", kind)
+}
+
+// ParseRetryPolicy parses "kind:base:maxDelay:maxAttempts", e.g.
+// "exponential:1s:5m:5" or "fixed:500ms:500ms:2".
+func ParseRetryPolicy(spec string) (RetryPolicy, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("retry policy %q must be kind:base:maxDelay:maxAttempts", spec)
+	}
+
+	base, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("retry policy %q: invalid base: %w", spec, err)
+	}
+	maxDelay, err := time.ParseDuration(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("retry policy %q: invalid max delay: %w", spec, err)
+	}
+	maxAttempts, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("retry policy %q: invalid max attempts: %w", spec, err)
+	}
+
+	policy, err := NewRetryPolicy(parts[0], base, maxDelay, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", spec, err)
+	}
+
+	return policy, nil
+}
+
+// RetryPolicies resolves the policy of an event key: an exact match first,
+// then the longest "prefix*" pattern, then the default policy.
+type RetryPolicies struct {
+	Default RetryPolicy
+	ByKey   map[string]RetryPolicy
+}
+
+// NewRetryPolicies parses the default policy and the per event key policies,
+// all in the format of ParseRetryPolicy.
+func NewRetryPolicies(defaultSpec string, specs map[string]string) (*RetryPolicies, error) {
+	defaultPolicy, err := ParseRetryPolicy(defaultSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &RetryPolicies{Default: defaultPolicy, ByKey: map[string]RetryPolicy{}}
+	for key, spec := range specs {
+		policy, err := ParseRetryPolicy(spec)
+		if err != nil {
+			return nil, fmt.Errorf("event key %q: %w", key, err)
+		}
+		p.ByKey[key] = policy
+	}
+
+	return p, nil
+}
+
+func (p *RetryPolicies) For(eventKey string) RetryPolicy {
+	if policy, ok := lookupKey(p.ByKey, eventKey); ok {
+		return policy
+	}
+
+	return p.Default
+}
+
+// ForError is For, unless err is a *PublishError that brings its own policy.
+func (p *RetryPolicies) ForError(eventKey string, err error) RetryPolicy {
+	var publishErr *PublishError
+	if errors.As(err, &publishErr) && publishErr.RetryPolicy != nil {
+		return publishErr.RetryPolicy
+	}
+
+	return p.For(eventKey)
+}
+
+// lookupKey finds the entry of an event key: an exact match first, then the
+// longest "prefix*" pattern.
+func lookupKey[T any](entries map[string]T, eventKey string) (T, bool) {
+	if entry, ok := entries[eventKey]; ok {
+		return entry, true
+	}
+
+	var (
+		match   T
+		found   bool
+		longest int
+	)
+	for pattern, entry := range entries {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(eventKey, prefix) && len(prefix) >= longest {
+			match, found, longest = entry, true, len(prefix)
+		}
+	}
+
+	return match, found
+}
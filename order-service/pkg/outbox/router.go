@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+)
+
+// Router is a Publisher that picks the publisher of every event by its event
+// key, so one relay can feed several brokers. Keys are matched like
+// RetryPolicies: an exact match first, then the longest "prefix*" pattern,
+// then the default publisher.
+type Router struct {
+	defaultPublisher Publisher
+	routes           map[string]Publisher
+}
+
+func NewRouter(defaultPublisher Publisher, routes map[string]Publisher) *Router {
+	return &Router{defaultPublisher: defaultPublisher, routes: routes}
+}
+
+func (r *Router) publisherFor(eventKey string) Publisher {
+	if publisher, ok := lookupKey(r.routes, eventKey); ok {
+		return publisher
+	}
+	return r.defaultPublisher
+}
+
+// OpenSession opens the sessions of the routed publishers on first use.
+func (r *Router) OpenSession(ctx context.Context) (Session, error) {
+	return &routerSession{router: r, sessions: map[Publisher]Session{}}, nil
+}
+
+type routerSession struct {
+	router   *Router
+	sessions map[Publisher]Session
+	used     []Session // published to since the last Confirm
+}
+
+func (s *routerSession) Publish(ctx context.Context, event *Event) error {
+	return s.send(ctx, event, func(session Session) error { return session.Publish(ctx, event) })
+}
+
+// DeadLetter uses the dead-letter destination of the event's own publisher.
+func (s *routerSession) DeadLetter(ctx context.Context, event *Event, cause error) error {
+	return s.send(ctx, event, func(session Session) error { return session.DeadLetter(ctx, event, cause) })
+}
+
+func (s *routerSession) send(ctx context.Context, event *Event, publish func(Session) error) error {
+	publisher := s.router.publisherFor(event.EventKey)
+
+	session, ok := s.sessions[publisher]
+	if !ok {
+		var err error
+		if session, err = publisher.OpenSession(ctx); err != nil {
+			return err
+		}
+		s.sessions[publisher] = session
+	}
+
+	if err := publish(session); err != nil {
+		return err
+	}
+
+	for _, used := range s.used {
+		if used == session {
+			return nil
+		}
+	}
+	s.used = append(s.used, session)
+
+	return nil
+}
+
+func (s *routerSession) Confirm(ctx context.Context) error {
+	used := s.used
+	s.used = nil
+
+	for _, session := range used {
+		if err := session.Confirm(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *routerSession) Close() error {
+	var errs []error
+	for publisher, session := range s.sessions {
+		errs = append(errs, session.Close())
+		delete(s.sessions, publisher)
+	}
+	s.used = nil
+
+	return errors.Join(errs...)
+}
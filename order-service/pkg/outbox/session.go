@@ -0,0 +1,55 @@
+package outbox
+
+import "context"
+
+// SessionHolder keeps one Session of a Publisher open for a single goroutine.
+// The session is opened on first use and replaced after a connection error.
+type SessionHolder struct {
+	publisher Publisher
+	session   Session
+}
+
+func NewSessionHolder(publisher Publisher) *SessionHolder {
+	return &SessionHolder{publisher: publisher}
+}
+
+// Publish publishes the event and waits for the broker's confirm.
+func (h *SessionHolder) Publish(ctx context.Context, event *Event) error {
+	return h.send(ctx, func(s Session) error { return s.Publish(ctx, event) })
+}
+
+// DeadLetter dead-letters the event and waits for the broker's confirm.
+func (h *SessionHolder) DeadLetter(ctx context.Context, event *Event, cause error) error {
+	return h.send(ctx, func(s Session) error { return s.DeadLetter(ctx, event, cause) })
+}
+
+// Close closes the current session, a later publish opens a new one.
+func (h *SessionHolder) Close() error {
+	if h.session == nil {
+		return nil
+	}
+
+	err := h.session.Close()
+	h.session = nil
+	return err
+}
+
+func (h *SessionHolder) send(ctx context.Context, publish func(Session) error) error {
+	if h.session == nil {
+		session, err := h.publisher.OpenSession(ctx)
+		if err != nil {
+			return err
+		}
+		h.session = session
+	}
+
+	err := publish(h.session)
+	if err == nil {
+		err = h.session.Confirm(ctx)
+	}
+	if err != nil && Classify(err) == ErrorClassConnection {
+		_ = h.Close()
+	}
+
+	return err
+}
@@ -0,0 +1,21 @@
+package outbox
+
+import "context"
+
+// Store persists outbox events. Claim must be safe to call from several
+// relays at once, e.g. by locking rows with FOR UPDATE SKIP LOCKED.
+type Store interface {
+	// Insert adds a pending event as part of tx, the producer's transaction.
+	// The type of tx depends on the implementation, e.g. *gorm.DB.
+	Insert(ctx context.Context, tx any, event *Event) error
+	// Claim locks up to limit due events for owner. An event is due when it is
+	// pending and its retry time has passed, or when its claim expired, and no
+	// earlier event of the same aggregate is still open.
+	Claim(ctx context.Context, owner string, limit int) ([]*Event, error)
+	// UpdateState applies update only while the event is still claimed by
+	// event.LockedBy, and reports whether it did.
+	UpdateState(ctx context.Context, event *Event, update *StateUpdate) (bool, error)
+	// RenewLocks extends the claims owner still holds on the given events.
+	RenewLocks(ctx context.Context, owner string, ids []string) (int64, error)
+	Count(ctx context.Context) (*Counts, error)
+}
@@ -0,0 +1,157 @@
+package outbox_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+// memStore is an outbox.Store kept in memory, with the claim rules of
+// gormstore: due pending events in insert order, one open event per aggregate.
+type memStore struct {
+	mu     sync.Mutex
+	events []*storedEvent
+}
+
+type storedEvent struct {
+	event       outbox.Event
+	status      string
+	nextRetryAt time.Time
+}
+
+func newMemStore(events ...*outbox.Event) *memStore {
+	s := &memStore{}
+	for _, event := range events {
+		_ = s.Insert(context.Background(), nil, event)
+	}
+	return s
+}
+
+func (s *memStore) Insert(ctx context.Context, tx any, event *outbox.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.AggregateID != "" && event.Sequence == 0 {
+		for _, e := range s.events {
+			if e.event.AggregateID == event.AggregateID {
+				event.Sequence = max(event.Sequence, e.event.Sequence)
+			}
+		}
+		event.Sequence++
+	}
+
+	s.events = append(s.events, &storedEvent{event: *event, status: outbox.StatusPending})
+	return nil
+}
+
+func (s *memStore) Claim(ctx context.Context, owner string, limit int) ([]*outbox.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []*outbox.Event
+	for _, e := range s.events {
+		if len(claimed) == limit {
+			break
+		}
+		if e.status != outbox.StatusPending || e.nextRetryAt.After(time.Now()) || s.blocked(e) {
+			continue
+		}
+
+		e.status = outbox.StatusInProgress
+		e.event.LockedBy = owner
+		event := e.event
+		claimed = append(claimed, &event)
+	}
+
+	return claimed, nil
+}
+
+// blocked reports whether an earlier event of the same aggregate is still open.
+func (s *memStore) blocked(e *storedEvent) bool {
+	if e.event.AggregateID == "" {
+		return false
+	}
+	for _, prev := range s.events {
+		if prev.event.AggregateID == e.event.AggregateID &&
+			prev.event.Sequence < e.event.Sequence &&
+			(prev.status == outbox.StatusPending || prev.status == outbox.StatusInProgress) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memStore) UpdateState(ctx context.Context, event *outbox.Event, update *outbox.StateUpdate) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.find(event.ID)
+	if e == nil || e.status != outbox.StatusInProgress || e.event.LockedBy != event.LockedBy {
+		return false, nil
+	}
+
+	e.status = update.Status
+	e.event.LockedBy = ""
+	if update.Status == outbox.StatusPending {
+		e.event.RetryCount = update.RetryCount
+		e.nextRetryAt = update.NextRetryAt
+	}
+
+	return true, nil
+}
+
+func (s *memStore) RenewLocks(ctx context.Context, owner string, ids []string) (int64, error) {
+	return int64(len(ids)), nil
+}
+
+func (s *memStore) Count(ctx context.Context) (*outbox.Counts, error) {
+	return &outbox.Counts{}, nil
+}
+
+func (s *memStore) find(id string) *storedEvent {
+	for _, e := range s.events {
+		if e.event.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// state returns the status, retry count and owner of an event.
+func (s *memStore) state(id string) (status string, retryCount int, lockedBy string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.find(id)
+	return e.status, e.event.RetryCount, e.event.LockedBy
+}
+
+// countStatus returns the number of events in status.
+func (s *memStore) countStatus(status string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, e := range s.events {
+		if e.status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// eventually fails the test unless cond becomes true within five seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
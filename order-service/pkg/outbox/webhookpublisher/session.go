@@ -0,0 +1,153 @@
+package webhookpublisher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// session delivers on Confirm, a webhook has no separate acknowledgement.
+type session struct {
+	publisher  *Publisher
+	pending    []*outbox.Event
+	deadLetter *outbox.SessionHolder
+}
+
+func (s *session) Publish(ctx context.Context, event *outbox.Event) error {
+	s.pending = append(s.pending, event)
+	return nil
+}
+
+func (s *session) DeadLetter(ctx context.Context, event *outbox.Event, cause error) error {
+	if s.publisher.deadLetter == nil {
+		return &outbox.PublishError{Class: outbox.ErrorClassPermanent, Op: "dead-letter", Err: ErrNoDeadLetter}
+	}
+	if s.deadLetter == nil {
+		s.deadLetter = outbox.NewSessionHolder(s.publisher.deadLetter)
+	}
+
+	return s.deadLetter.DeadLetter(ctx, event, cause)
+}
+
+func (s *session) Confirm(ctx context.Context) error {
+	pending := s.pending
+	s.pending = nil
+
+	for _, event := range pending {
+		if err := s.deliver(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *session) Close() error {
+	s.pending = nil
+	if s.deadLetter != nil {
+		return s.deadLetter.Close()
+	}
+	return nil
+}
+
+// deliver sends the event to every endpoint that still needs it. All of them
+// are tried. A transient failure wins over a permanent one, so endpoints that
+// may recover are retried before the event is dead-lettered.
+func (s *session) deliver(ctx context.Context, event *outbox.Event) error {
+	endpoints, err := s.publisher.subscriptions.Endpoints(ctx, event)
+	if err != nil {
+		return &outbox.PublishError{Class: outbox.ErrorClassTransient, Op: "subscriptions", Err: err}
+	}
+
+	var failure error
+	for _, endpoint := range endpoints {
+		err := s.send(ctx, endpoint, event)
+		if err == nil {
+			err = s.publisher.subscriptions.Delivered(ctx, event, endpoint)
+		}
+		if err != nil && (failure == nil || outbox.Classify(failure) == outbox.ErrorClassPermanent) {
+			failure = err
+		}
+	}
+
+	return failure
+}
+
+func (s *session) send(ctx context.Context, endpoint *Endpoint, event *outbox.Event) error {
+	timeout := endpoint.Timeout
+	if timeout <= 0 {
+		timeout = s.publisher.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(event.Payload))
+	if err != nil {
+		return endpointError(endpoint, outbox.ErrorClassPermanent, err)
+	}
+
+	now := time.Now()
+	req.Header.Set("Content-Type", event.PayloadContentType())
+	if event.SchemaRef != "" {
+		req.Header.Set(HeaderSchema, event.SchemaRef)
+	}
+	req.Header.Set(HeaderID, event.ID)
+	req.Header.Set(HeaderEventKey, event.EventKey)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(now.Unix()))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, now, event.Payload))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	delivery := &Delivery{Endpoint: endpoint, Event: event}
+	defer func() {
+		delivery.Duration = time.Since(now)
+		if s.publisher.observe != nil {
+			s.publisher.observe(delivery)
+		}
+	}()
+
+	resp, err := s.publisher.client.Do(req)
+	if err != nil {
+		delivery.Err = endpointError(endpoint, outbox.ErrorClassTransient, err)
+		return delivery.Err
+	}
+	defer resp.Body.Close()
+	// drained so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.StatusCode = resp.StatusCode
+	if class, failed := classifyStatus(resp.StatusCode); failed {
+		delivery.Err = endpointError(endpoint, class, fmt.Errorf("unexpected status %d", resp.StatusCode))
+	}
+
+	return delivery.Err
+}
+
+func classifyStatus(code int) (outbox.ErrorClass, bool) {
+	switch {
+	case code >= 200 && code < 300:
+		return "", false
+	// the endpoint asked to be called again later
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests:
+		return outbox.ErrorClassTransient, true
+	case code >= 500:
+		return outbox.ErrorClassTransient, true
+	default:
+		return outbox.ErrorClassPermanent, true
+	}
+}
+
+func endpointError(endpoint *Endpoint, class outbox.ErrorClass, err error) *outbox.PublishError {
+	return &outbox.PublishError{
+		Class:       class,
+		Op:          "deliver",
+		Err:         fmt.Errorf("endpoint %s: %w", endpoint.ID, err),
+		RetryPolicy: endpoint.RetryPolicy,
+	}
+}
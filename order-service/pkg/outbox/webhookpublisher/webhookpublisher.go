@@ -0,0 +1,141 @@
+// Package webhookpublisher is an outbox.Publisher that POSTs every event to
+// the HTTP endpoints subscribed to its event key.
+//
+// Requests carry the event ID, a Unix timestamp and an HMAC-SHA256 signature
+// of "<timestamp>.<body>" with the endpoint's secret, so receivers can verify
+// the sender, reject replays and dedupe redeliveries:
+//
+//	Webhook-Id: <event ID>
+//	Webhook-Timestamp: 1700000000
+//	Webhook-Signature: sha256=<hex>
+//
+// 2xx responses are successes, 408, 429 and 5xx responses and network errors
+// are transient, any other response is a permanent failure.
+package webhookpublisher
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sagarmaheshwary/transactional-outbox-rabbitmq/order-service/pkg/outbox"
+)
+
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEventKey  = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+	HeaderSchema    = "Webhook-Schema" // set when the event has a schema reference
+)
+
+var ErrNoDeadLetter = errors.New("webhook: no dead-letter publisher configured")
+
+// Endpoint is one subscriber of events.
+type Endpoint struct {
+	ID      string
+	URL     string
+	Secret  string
+	Timeout time.Duration // zero uses the publisher's default
+	// RetryPolicy replaces the event key's policy when this endpoint fails.
+	RetryPolicy outbox.RetryPolicy
+}
+
+// Subscriptions resolves the endpoints of an event.
+type Subscriptions interface {
+	// Endpoints returns the endpoints subscribed to the event's key that did
+	// not receive the event yet.
+	Endpoints(ctx context.Context, event *outbox.Event) ([]*Endpoint, error)
+	// Delivered records a successful delivery, so a retry of the event caused
+	// by another endpoint skips this one.
+	Delivered(ctx context.Context, event *outbox.Event, endpoint *Endpoint) error
+}
+
+// Delivery describes one request, for metrics and logging.
+type Delivery struct {
+	Endpoint   *Endpoint
+	Event      *outbox.Event
+	StatusCode int // zero when no response was received
+	Duration   time.Duration
+	Err        error
+}
+
+type Publisher struct {
+	subscriptions Subscriptions
+	client        *http.Client
+	timeout       time.Duration
+	deadLetter    outbox.Publisher
+	observe       func(*Delivery)
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithHTTPClient sets the client requests are sent with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Publisher) { p.client = client }
+}
+
+// WithTimeout sets the timeout of endpoints without their own. Defaults to 5s.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Publisher) { p.timeout = d }
+}
+
+// WithDeadLetter hands dead-lettered events to another publisher, e.g. the
+// dead-letter queue of a broker. Webhooks have no dead-letter destination.
+func WithDeadLetter(publisher outbox.Publisher) Option {
+	return func(p *Publisher) { p.deadLetter = publisher }
+}
+
+// WithObserver is called after every request.
+func WithObserver(observe func(*Delivery)) Option {
+	return func(p *Publisher) { p.observe = observe }
+}
+
+func New(subscriptions Subscriptions, opts ...Option) *Publisher {
+	p := &Publisher{
+		subscriptions: subscriptions,
+		client:        http.DefaultClient,
+		timeout:       5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *Publisher) OpenSession(ctx context.Context) (outbox.Session, error) {
+	return &session{publisher: p}, nil
+}
+
+// Sign returns the signature header value of body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header, for receivers. Timestamps further than
+// tolerance from now are rejected.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	sentAt := time.Unix(unix, 0)
+	if age := time.Since(sentAt); age > tolerance || age < -tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, sentAt, body)))
+}
@@ -0,0 +1,232 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var errLeaseLost = errors.New("outbox event lease lost to another relay")
+
+// releaseDelay keeps a released event from being claimed again right away.
+const releaseDelay = time.Second
+
+func (r *Relay) work(ctx context.Context, queue <-chan *Event) {
+	session := NewSessionHolder(r.publisher)
+	defer session.Close()
+
+	for event := range queue {
+		if r.isStopping() {
+			r.release(ctx, []*Event{event})
+			continue
+		}
+
+		r.process(ctx, session, event)
+	}
+}
+
+func (r *Relay) process(ctx context.Context, session *SessionHolder, event *Event) {
+	ctx, finish := r.opts.hooks.startEvent(ctx, event)
+
+	outcome := OutcomePublished
+	event, err := r.decrypt(ctx, event)
+	if err == nil {
+		err = r.publish(ctx, session, event)
+	}
+	if err != nil {
+		outcome = r.handlePublishError(ctx, session, event, err)
+	} else {
+		r.markPublished(ctx, event)
+		// the next event of this aggregate only becomes claimable now
+		if event.AggregateID != "" {
+			r.Wake()
+		}
+	}
+
+	r.leases.release(event.ID)
+	finish(outcome)
+}
+
+// decrypt returns a copy of event with its plaintext payload. The payload
+// only exists in plaintext for the duration of the publish.
+func (r *Relay) decrypt(ctx context.Context, event *Event) (*Event, error) {
+	plain, err := DecryptEvent(r.opts.cipher, event)
+	if err != nil {
+		r.opts.logger.ErrorContext(ctx, "Failed to decrypt outbox event",
+			"error", err.Error(),
+			"event_id", event.ID,
+			"key_id", event.Encryption.KeyID,
+		)
+	}
+	return plain, err
+}
+
+func (r *Relay) publish(ctx context.Context, session *SessionHolder, event *Event) error {
+	r.opts.logger.InfoContext(ctx, "Publishing outbox event",
+		"event_id", event.ID,
+		"event_key", event.EventKey,
+		"retry_count", event.RetryCount,
+	)
+
+	err := session.Publish(ctx, event)
+	if err != nil {
+		class := Classify(err)
+		r.opts.hooks.publishFailed(ctx, event, class, err)
+		r.opts.logger.ErrorContext(ctx, "Failed to publish outbox event",
+			"error", err.Error(),
+			"event_id", event.ID,
+			"event_key", event.EventKey,
+			"class", string(class),
+		)
+		return err
+	}
+
+	r.opts.hooks.published(ctx, event)
+	return nil
+}
+
+// handlePublishError reacts to the class of a publish error. Only transient
+// errors consume the event's retry budget.
+func (r *Relay) handlePublishError(ctx context.Context, session *SessionHolder, event *Event, err error) Outcome {
+	switch Classify(err) {
+	case ErrorClassPermanent:
+		r.deadLetter(ctx, session, event, err)
+		return OutcomePermanent
+
+	case ErrorClassConnection:
+		// The session is reopened on the next publish, the event is not to blame.
+		r.releaseEvent(ctx, event)
+		return OutcomeConnection
+	}
+
+	outcome := r.handleFailure(ctx, session, event, err)
+	if errors.Is(err, ErrUnroutable) {
+		outcome = OutcomeUnroutable
+	}
+	return outcome
+}
+
+func (r *Relay) handleFailure(ctx context.Context, session *SessionHolder, event *Event, err error) Outcome {
+	policy := r.opts.retryPolicies.ForError(event.EventKey, err)
+
+	if event.RetryCount >= policy.MaxAttempts() {
+		r.opts.hooks.retriesExhausted(ctx, event)
+		return r.deadLetter(ctx, session, event, err)
+	}
+
+	event.RetryCount++
+	delay := policy.Delay(event.RetryCount)
+
+	r.opts.logger.InfoContext(ctx, "Scheduling retry for outbox event",
+		"event_id", event.ID,
+		"retry_count", event.RetryCount,
+		"backoff_seconds", delay.Seconds(),
+	)
+
+	err = r.updateOwned(ctx, event, &StateUpdate{
+		Status:      StatusPending,
+		RetryCount:  event.RetryCount,
+		NextRetryAt: time.Now().Add(delay),
+	})
+	if err != nil {
+		r.opts.logger.ErrorContext(ctx, "Failed to schedule retry for outbox event",
+			"error", err.Error(),
+			"event_id", event.ID,
+		)
+		return OutcomeFailed
+	}
+
+	r.opts.hooks.retryScheduled(ctx, event, delay)
+	return OutcomeRetry
+}
+
+// deadLetter marks the event failed before handing it to the dead-letter
+// destination, so a lost lease never leads to a dead-lettered duplicate.
+func (r *Relay) deadLetter(ctx context.Context, session *SessionHolder, event *Event, cause error) Outcome {
+	err := r.updateOwned(ctx, event, &StateUpdate{
+		Status:        StatusFailed,
+		FailureReason: cause.Error(),
+		FailedAt:      time.Now(),
+	})
+	if err != nil {
+		r.opts.logger.ErrorContext(ctx, "Failed to mark outbox event as failed",
+			"error", err.Error(),
+			"event_id", event.ID,
+		)
+		return OutcomeFailed
+	}
+
+	err = session.DeadLetter(ctx, event, cause)
+	r.opts.hooks.deadLettered(ctx, event, err)
+	if err != nil {
+		r.opts.logger.ErrorContext(ctx, "Failed to dead-letter outbox event",
+			"error", err.Error(),
+			"event_id", event.ID,
+		)
+	} else {
+		r.opts.logger.InfoContext(ctx, "Outbox event dead-lettered",
+			"event_id", event.ID,
+			"event_key", event.EventKey,
+			"error", cause.Error(),
+		)
+	}
+
+	return OutcomeDLQ
+}
+
+func (r *Relay) markPublished(ctx context.Context, event *Event) {
+	if err := r.updateOwned(ctx, event, &StateUpdate{Status: StatusPublished}); err != nil {
+		r.opts.logger.ErrorContext(ctx, "Failed to mark outbox event as published",
+			"error", err.Error(),
+			"event_id", event.ID,
+		)
+	}
+}
+
+// releaseEvent hands an event back to pending without counting the attempt,
+// for failures that were not caused by the event itself.
+func (r *Relay) releaseEvent(ctx context.Context, event *Event) {
+	err := r.updateOwned(ctx, event, &StateUpdate{
+		Status:      StatusPending,
+		RetryCount:  event.RetryCount,
+		NextRetryAt: time.Now().Add(releaseDelay),
+	})
+	if err != nil {
+		r.opts.logger.ErrorContext(ctx, "Failed to release outbox event",
+			"error", err.Error(),
+			"event_id", event.ID,
+		)
+	}
+}
+
+// release hands claimed events back to pending when the relay stops before
+// they were published.
+func (r *Relay) release(ctx context.Context, events []*Event) {
+	for _, event := range events {
+		r.releaseEvent(ctx, event)
+		r.leases.release(event.ID)
+	}
+
+	r.opts.hooks.released(len(events))
+	r.opts.logger.Info("Released unstarted outbox events", "count", len(events))
+}
+
+// updateOwned moves an in-progress event to its next state, provided this
+// relay still owns it.
+func (r *Relay) updateOwned(ctx context.Context, event *Event, update *StateUpdate) error {
+	updated, err := r.store.UpdateState(ctx, event, update)
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		r.opts.hooks.leaseLost(ctx, event)
+		r.opts.logger.WarnContext(ctx, "Outbox event lease lost, leaving it to the new owner",
+			"event_id", event.ID,
+			"locked_by", event.LockedBy,
+		)
+		return errLeaseLost
+	}
+
+	return nil
+}
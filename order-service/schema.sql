@@ -5,13 +5,20 @@ CREATE TABLE
     created_at TIMESTAMP DEFAULT now ()
   );
 
-CREATE TYPE OutboxEventStatus as ENUM ('pending', 'in_progress', 'published', 'failed');
+CREATE TYPE OutboxEventStatus as ENUM ('pending', 'in_progress', 'published', 'failed', 'cancelled');
 
 CREATE TABLE
   outbox_events (
     id TEXT PRIMARY KEY,
     event_key TEXT NOT NULL,
-    payload JSONB NOT NULL,
+    aggregate_id TEXT NOT NULL DEFAULT '',
+    sequence BIGINT NOT NULL DEFAULT 0,
+    payload BYTEA NOT NULL,
+    content_type TEXT NOT NULL DEFAULT 'application/json',
+    schema_ref TEXT NOT NULL DEFAULT '',
+    key_id TEXT NOT NULL DEFAULT '',
+    wrapped_key BYTEA DEFAULT NULL,
+    encryption_scope TEXT NOT NULL DEFAULT '',
     status OutboxEventStatus NOT NULL,
     retry_count INT DEFAULT 0,
     next_retry_at TIMESTAMP DEFAULT NULL,
@@ -29,4 +36,90 @@ WHERE
 
 CREATE INDEX idx_outbox_events_retryable ON outbox_events (locked_at, next_retry_at, created_at)
 WHERE
-  status = 'in_progress';
\ No newline at end of file
+  status = 'in_progress';
+
+CREATE UNIQUE INDEX idx_outbox_events_aggregate_sequence ON outbox_events (aggregate_id, sequence)
+WHERE
+  aggregate_id <> '';
+
+CREATE INDEX idx_outbox_events_aggregate_open ON outbox_events (aggregate_id, sequence)
+WHERE
+  status IN ('pending', 'in_progress');
+
+-- Published and failed events past their retention are moved here (OUTBOX_RETENTION_ARCHIVE=true).
+CREATE TABLE
+  outbox_events_archive (
+    LIKE outbox_events INCLUDING DEFAULTS,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW ()
+  );
+
+CREATE INDEX idx_outbox_events_archive_created_at ON outbox_events_archive (created_at);
+
+-- Encrypted payloads whose data key is not wrapped with the primary key yet are rewrapped by key_id.
+CREATE INDEX idx_outbox_events_key_id ON outbox_events (key_id)
+WHERE
+  key_id <> '';
+
+CREATE INDEX idx_outbox_events_archive_key_id ON outbox_events_archive (key_id)
+WHERE
+  key_id <> '';
+
+CREATE TABLE
+  outbox_admin_audit (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    filter JSONB NOT NULL,
+    affected BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW ()
+  );
+
+CREATE TABLE
+  outbox_redrives (
+    event_id TEXT NOT NULL,
+    failed_at TIMESTAMP NOT NULL,
+    mode TEXT NOT NULL,
+    routing_key TEXT NOT NULL,
+    redriven_at TIMESTAMP DEFAULT NOW (),
+    PRIMARY KEY (event_id, failed_at)
+  );
+
+-- Endpoints of the webhook transport, managed through /admin/webhooks.
+CREATE TABLE
+  webhook_subscriptions (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_keys JSONB NOT NULL,
+    timeout_ms INT NOT NULL DEFAULT 0,
+    retry_policy TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW (),
+    updated_at TIMESTAMP DEFAULT NOW ()
+  );
+
+CREATE TABLE
+  webhook_deliveries (
+    event_id TEXT NOT NULL,
+    subscription_id TEXT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    delivered_at TIMESTAMP DEFAULT NOW (),
+    PRIMARY KEY (event_id, subscription_id)
+  );
+
+CREATE INDEX idx_webhook_deliveries_delivered_at ON webhook_deliveries (delivered_at);
+
+CREATE TABLE
+  outbox_deliveries (
+    event_id TEXT NOT NULL,
+    destination TEXT NOT NULL,
+    delivered_at TIMESTAMP DEFAULT NOW (),
+    PRIMARY KEY (event_id, destination)
+  );
+
+CREATE INDEX idx_outbox_deliveries_delivered_at ON outbox_deliveries (delivered_at);
+
+-- Used by the CDC relay mode (OUTBOX_RELAY_MODE=cdc), requires wal_level=logical.
+CREATE PUBLICATION outbox_events_pub FOR TABLE outbox_events
+WITH
+  (publish = 'insert');
@@ -0,0 +1,126 @@
+-- Alternative to schema.sql with outbox_events range-partitioned by day on created_at.
+-- Use together with OUTBOX_PARTITIONING_ENABLED=true.
+
+CREATE TABLE
+  orders (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP DEFAULT now ()
+  );
+
+CREATE TYPE OutboxEventStatus as ENUM ('pending', 'in_progress', 'published', 'failed', 'cancelled');
+
+CREATE TABLE
+  outbox_events (
+    id TEXT NOT NULL,
+    event_key TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL DEFAULT '',
+    sequence BIGINT NOT NULL DEFAULT 0,
+    payload BYTEA NOT NULL,
+    content_type TEXT NOT NULL DEFAULT 'application/json',
+    schema_ref TEXT NOT NULL DEFAULT '',
+    key_id TEXT NOT NULL DEFAULT '',
+    wrapped_key BYTEA DEFAULT NULL,
+    encryption_scope TEXT NOT NULL DEFAULT '',
+    status OutboxEventStatus NOT NULL,
+    retry_count INT DEFAULT 0,
+    next_retry_at TIMESTAMP DEFAULT NULL,
+    locked_at TIMESTAMP DEFAULT NULL,
+    locked_by VARCHAR(128) NULL,
+    failure_reason VARCHAR(128) DEFAULT NULL,
+    failed_at TIMESTAMP DEFAULT NULL,
+    traceparent TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW (),
+    -- The partition key has to be part of every unique constraint.
+    PRIMARY KEY (id, created_at)
+  )
+PARTITION BY
+  RANGE (created_at);
+
+-- Catches rows outside the daily partitions, which are created ahead of time
+-- by the service (OUTBOX_PARTITIONING_ENABLED=true). It is never removed.
+CREATE TABLE outbox_events_default PARTITION OF outbox_events DEFAULT;
+
+CREATE INDEX idx_outbox_events_pending_ready ON outbox_events (created_at)
+WHERE
+  status = 'pending';
+
+CREATE INDEX idx_outbox_events_retryable ON outbox_events (locked_at, next_retry_at, created_at)
+WHERE
+  status = 'in_progress';
+
+-- Cannot be unique without created_at; sequences are still serialized by the
+-- per-aggregate advisory lock taken on insert.
+CREATE INDEX idx_outbox_events_aggregate_sequence ON outbox_events (aggregate_id, sequence)
+WHERE
+  aggregate_id <> '';
+
+CREATE INDEX idx_outbox_events_aggregate_open ON outbox_events (aggregate_id, sequence)
+WHERE
+  status IN ('pending', 'in_progress');
+
+-- Encrypted payloads whose data key is not wrapped with the primary key yet are rewrapped by key_id.
+CREATE INDEX idx_outbox_events_key_id ON outbox_events (key_id)
+WHERE
+  key_id <> '';
+
+CREATE TABLE
+  outbox_admin_audit (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    filter JSONB NOT NULL,
+    affected BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW ()
+  );
+
+CREATE TABLE
+  outbox_redrives (
+    event_id TEXT NOT NULL,
+    failed_at TIMESTAMP NOT NULL,
+    mode TEXT NOT NULL,
+    routing_key TEXT NOT NULL,
+    redriven_at TIMESTAMP DEFAULT NOW (),
+    PRIMARY KEY (event_id, failed_at)
+  );
+
+-- Endpoints of the webhook transport, managed through /admin/webhooks.
+CREATE TABLE
+  webhook_subscriptions (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_keys JSONB NOT NULL,
+    timeout_ms INT NOT NULL DEFAULT 0,
+    retry_policy TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW (),
+    updated_at TIMESTAMP DEFAULT NOW ()
+  );
+
+CREATE TABLE
+  webhook_deliveries (
+    event_id TEXT NOT NULL,
+    subscription_id TEXT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    delivered_at TIMESTAMP DEFAULT NOW (),
+    PRIMARY KEY (event_id, subscription_id)
+  );
+
+CREATE INDEX idx_webhook_deliveries_delivered_at ON webhook_deliveries (delivered_at);
+
+CREATE TABLE
+  outbox_deliveries (
+    event_id TEXT NOT NULL,
+    destination TEXT NOT NULL,
+    delivered_at TIMESTAMP DEFAULT NOW (),
+    PRIMARY KEY (event_id, destination)
+  );
+
+CREATE INDEX idx_outbox_deliveries_delivered_at ON outbox_deliveries (delivered_at);
+
+-- Used by the CDC relay mode (OUTBOX_RELAY_MODE=cdc), requires wal_level=logical.
+-- Changes are published under outbox_events rather than the partition names.
+CREATE PUBLICATION outbox_events_pub FOR TABLE outbox_events
+WITH
+  (publish = 'insert', publish_via_partition_root = true);